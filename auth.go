@@ -0,0 +1,221 @@
+package routing
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+const defaultAuthRealm = "Restricted"
+
+type principalKey int
+
+var principalCtxKey principalKey
+
+// CredentialsValidator validates the username and password of a request,
+// returning the authenticated principal if they are valid
+type CredentialsValidator func(r *http.Request, username, password string) (principal interface{}, ok bool)
+
+// TokenValidator validates the token or API key of a request, returning the
+// authenticated principal if it is valid
+type TokenValidator func(r *http.Request, token string) (principal interface{}, ok bool)
+
+// BasicAuthOptions configures the BasicAuth middleware
+type BasicAuthOptions struct {
+	// Realm is the protection space of the challenge, it defaults to
+	// Restricted
+	Realm string
+	// Validator validates the credentials of the requests
+	Validator CredentialsValidator
+}
+
+// BearerAuthOptions configures the BearerAuth middleware
+type BearerAuthOptions struct {
+	// Realm is the protection space of the challenge, it defaults to
+	// Restricted
+	Realm string
+	// Validator validates the bearer tokens of the requests
+	Validator TokenValidator
+}
+
+// APIKeyAuthOptions configures the APIKeyAuth middleware
+type APIKeyAuthOptions struct {
+	// Header is the header carrying the API key, it defaults to X-API-Key
+	Header string
+	// QueryParam is the query parameter carrying the API key when the header
+	// is missing, API keys are only read from the header when empty
+	QueryParam string
+	// Realm is the protection space of the challenge, it defaults to
+	// Restricted
+	Realm string
+	// Validator validates the API keys of the requests
+	Validator TokenValidator
+}
+
+// BasicAuth returns a Middleware authenticating the requests with the Basic
+// HTTP authentication scheme defined in RFC 7617. It panics if the options
+// have no Validator.
+func BasicAuth(options BasicAuthOptions) Middleware {
+	if options.Validator == nil {
+		panic("basic auth validator can not be nil")
+	}
+
+	realm := options.Realm
+	if realm == "" {
+		realm = defaultAuthRealm
+	}
+	challenge := "Basic realm=" + quoteAuthParam(realm) + `, charset="UTF-8"`
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			username, password, ok := r.BasicAuth()
+			if !ok {
+				unauthorized(w, challenge)
+				return
+			}
+
+			principal, ok := options.Validator(r, username, password)
+			if !ok {
+				unauthorized(w, challenge)
+				return
+			}
+
+			next(w, withPrincipal(r, principal))
+		}
+	}
+}
+
+// BearerAuth returns a Middleware authenticating the requests with the Bearer
+// HTTP authentication scheme defined in RFC 6750. It panics if the options
+// have no Validator.
+func BearerAuth(options BearerAuthOptions) Middleware {
+	if options.Validator == nil {
+		panic("bearer auth validator can not be nil")
+	}
+
+	realm := options.Realm
+	if realm == "" {
+		realm = defaultAuthRealm
+	}
+	challenge := "Bearer realm=" + quoteAuthParam(realm)
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			auth := r.Header.Get("Authorization")
+			if len(auth) < 7 || !strings.EqualFold(auth[:7], "bearer ") {
+				unauthorized(w, challenge)
+				return
+			}
+
+			principal, ok := options.Validator(r, strings.TrimSpace(auth[7:]))
+			if !ok {
+				unauthorized(w, challenge+`, error="invalid_token"`)
+				return
+			}
+
+			next(w, withPrincipal(r, principal))
+		}
+	}
+}
+
+// APIKeyAuth returns a Middleware authenticating the requests by an API key
+// sent in a header or a query parameter. It panics if the options have no
+// Validator.
+func APIKeyAuth(options APIKeyAuthOptions) Middleware {
+	if options.Validator == nil {
+		panic("api key auth validator can not be nil")
+	}
+
+	header := options.Header
+	if header == "" {
+		header = "X-API-Key"
+	}
+	realm := options.Realm
+	if realm == "" {
+		realm = defaultAuthRealm
+	}
+	challenge := "APIKey realm=" + quoteAuthParam(realm) + ", header=" + quoteAuthParam(header)
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(header)
+			if key == "" && options.QueryParam != "" {
+				key = r.URL.Query().Get(options.QueryParam)
+			}
+
+			if key == "" {
+				unauthorized(w, challenge)
+				return
+			}
+
+			principal, ok := options.Validator(r, key)
+			if !ok {
+				unauthorized(w, challenge)
+				return
+			}
+
+			next(w, withPrincipal(r, principal))
+		}
+	}
+}
+
+// StaticCredentials returns a CredentialsValidator accepting the given
+// passwords by username, the principal is the username
+func StaticCredentials(passwords map[string]string) CredentialsValidator {
+	return func(r *http.Request, username, password string) (interface{}, bool) {
+		expected, ok := passwords[username]
+		if !ok {
+			// compare anyway to not reveal which usernames exist
+			expected = password + "!"
+		}
+
+		return username, secureCompare(password, expected) && ok
+	}
+}
+
+// StaticTokens returns a TokenValidator accepting the given tokens, mapped to
+// their principals. All the tokens are compared in constant time.
+func StaticTokens(tokens map[string]interface{}) TokenValidator {
+	return func(r *http.Request, token string) (interface{}, bool) {
+		var principal interface{}
+		found := false
+		for t, p := range tokens {
+			if secureCompare(token, t) {
+				principal, found = p, true
+			}
+		}
+		return principal, found
+	}
+}
+
+// GetPrincipal returns the principal authenticated by the BasicAuth,
+// BearerAuth or APIKeyAuth middlewares, or nil if there is none
+func GetPrincipal(r *http.Request) interface{} {
+	return r.Context().Value(principalCtxKey)
+}
+
+func withPrincipal(r *http.Request, principal interface{}) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), principalCtxKey, principal))
+}
+
+func unauthorized(w http.ResponseWriter, challenge string) {
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, "401 unauthorized", http.StatusUnauthorized)
+}
+
+var authParamEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// quoteAuthParam returns the value of an authentication parameter as a quoted
+// string, escaping its quotes and backslashes
+func quoteAuthParam(value string) string {
+	return `"` + authParamEscaper.Replace(value) + `"`
+}
+
+// secureCompare compares two strings in constant time, hashing them first to
+// not leak their lengths
+func secureCompare(given, expected string) bool {
+	g, e := sha256.Sum256([]byte(given)), sha256.Sum256([]byte(expected))
+	return subtle.ConstantTimeCompare(g[:], e[:]) == 1
+}
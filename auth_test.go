@@ -0,0 +1,217 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func principalHandler(w http.ResponseWriter, r *http.Request) {
+	principal, _ := GetPrincipal(r).(string)
+	_, _ = w.Write([]byte(principal))
+}
+
+func TestBasicAuth(t *testing.T) {
+	handler := BasicAuth(BasicAuthOptions{
+		Realm:     "admin",
+		Validator: StaticCredentials(map[string]string{"alice": "secret"}),
+	})(principalHandler)
+
+	cases := []struct {
+		username, password string
+		code               int
+	}{
+		{"alice", "secret", http.StatusOK},
+		{"alice", "wrong", http.StatusUnauthorized},
+		{"bob", "secret", http.StatusUnauthorized},
+		{"", "", http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/", nil)
+		if c.username != "" {
+			req.SetBasicAuth(c.username, c.password)
+		}
+		res := httptest.NewRecorder()
+		handler(res, req)
+
+		assertEqual(t, c.code, res.Code)
+		if c.code == http.StatusOK {
+			assertStringEqual(t, "alice", res.Body.String())
+		} else {
+			assertStringEqual(t, `Basic realm="admin", charset="UTF-8"`, res.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	handler := BearerAuth(BearerAuthOptions{
+		Validator: StaticTokens(map[string]interface{}{"token-1": "alice"}),
+	})(principalHandler)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "bearer token-1")
+	res := httptest.NewRecorder()
+	handler(res, req)
+	assertEqual(t, http.StatusOK, res.Code)
+	assertStringEqual(t, "alice", res.Body.String())
+
+	req.Header.Set("Authorization", "Bearer token-2")
+	res = httptest.NewRecorder()
+	handler(res, req)
+	assertEqual(t, http.StatusUnauthorized, res.Code)
+	assertStringEqual(t, `Bearer realm="Restricted", error="invalid_token"`, res.Header().Get("WWW-Authenticate"))
+
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	res = httptest.NewRecorder()
+	handler(res, req)
+	assertEqual(t, http.StatusUnauthorized, res.Code)
+	assertStringEqual(t, `Bearer realm="Restricted"`, res.Header().Get("WWW-Authenticate"))
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	handler := APIKeyAuth(APIKeyAuthOptions{
+		QueryParam: "api_key",
+		Validator:  StaticTokens(map[string]interface{}{"key-1": "service"}),
+	})(principalHandler)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("X-API-Key", "key-1")
+	res := httptest.NewRecorder()
+	handler(res, req)
+	assertEqual(t, http.StatusOK, res.Code)
+	assertStringEqual(t, "service", res.Body.String())
+
+	req, _ = http.NewRequest("GET", "/?api_key=key-1", nil)
+	res = httptest.NewRecorder()
+	handler(res, req)
+	assertEqual(t, http.StatusOK, res.Code)
+
+	req, _ = http.NewRequest("GET", "/?api_key=key-2", nil)
+	res = httptest.NewRecorder()
+	handler(res, req)
+	assertEqual(t, http.StatusUnauthorized, res.Code)
+	assertStringEqual(t, `APIKey realm="Restricted", header="X-API-Key"`, res.Header().Get("WWW-Authenticate"))
+}
+
+func TestRouter_Middlewares_RequireAuthToRoutesAndGroups(t *testing.T) {
+	auth := BearerAuth(BearerAuthOptions{Validator: StaticTokens(map[string]interface{}{"token": "alice"})})
+
+	mainRouter := Router{}
+	adminRouter := NewRouter(RouterConfig{Middlewares: []Middleware{auth}})
+	reportsRouter := Router{}
+	secureRouter := NewRouter(RouterConfig{Middlewares: []Middleware{auth}})
+
+	_ = mainRouter.Get("/public", testHandlerFunc)
+	_ = mainRouter.Get("/private", principalHandler, MatchingOptions{Middlewares: []Middleware{auth}})
+	_ = adminRouter.Get("/users", principalHandler)
+	_ = reportsRouter.Get("/daily", testHandlerFunc)
+	_ = reportsRouter.Get("/daily", principalHandler)
+	_ = secureRouter.Prefix("/reports", &reportsRouter)
+	_ = mainRouter.Prefix("/admin", &adminRouter)
+	_ = mainRouter.Prefix("/secure", &secureRouter)
+
+	cases := []struct {
+		path  string
+		token string
+		code  int
+	}{
+		{"/public", "", http.StatusOK},
+		{"/private", "", http.StatusUnauthorized},
+		{"/private", "token", http.StatusOK},
+		{"/admin/users", "", http.StatusUnauthorized},
+		{"/admin/users", "token", http.StatusOK},
+		{"/secure/reports/daily", "", http.StatusUnauthorized},
+		{"/secure/reports/daily", "token", http.StatusOK},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", c.path, nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		res := httptest.NewRecorder()
+		mainRouter.ServeHTTP(res, req)
+
+		assertEqual(t, c.code, res.Code)
+	}
+}
+
+func TestAuth_PanicsWithoutValidator(t *testing.T) {
+	constructors := []func(){
+		func() { BasicAuth(BasicAuthOptions{}) },
+		func() { BearerAuth(BearerAuthOptions{}) },
+		func() { APIKeyAuth(APIKeyAuthOptions{}) },
+	}
+
+	for _, construct := range constructors {
+		func() {
+			defer func() {
+				assertNotNil(t, recover())
+			}()
+
+			construct()
+			t.Error("constructor did not panic")
+		}()
+	}
+}
+
+func TestAuth_EscapesChallengeParameters(t *testing.T) {
+	handler := BasicAuth(BasicAuthOptions{
+		Realm:     `admin "area" \ 1`,
+		Validator: StaticCredentials(map[string]string{}),
+	})(principalHandler)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	handler(res, req)
+
+	assertStringEqual(t, `Basic realm="admin \"area\" \\ 1", charset="UTF-8"`, res.Header().Get("WWW-Authenticate"))
+}
+
+func TestRouter_Middlewares_DoNotApplyToAutoMethodOptions(t *testing.T) {
+	auth := BearerAuth(BearerAuthOptions{Validator: StaticTokens(map[string]interface{}{"token": "alice"})})
+
+	mainRouter := NewRouter(RouterConfig{EnableAutoMethodOptions: true})
+	_ = mainRouter.Get("/private", principalHandler, MatchingOptions{Middlewares: []Middleware{auth}})
+
+	req, _ := http.NewRequest("OPTIONS", "/private", nil)
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusNoContent, res.Code)
+	assertStringContains(t, "GET", res.Header().Get("Allow"))
+
+	req, _ = http.NewRequest("GET", "/private", nil)
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusUnauthorized, res.Code)
+}
+
+func TestRouter_Middlewares_OfRoutersDoNotApplyToAutoMethodOptions(t *testing.T) {
+	auth := BearerAuth(BearerAuthOptions{Validator: StaticTokens(map[string]interface{}{"token": "alice"})})
+
+	mainRouter := NewRouter(RouterConfig{Middlewares: []Middleware{auth}})
+	apiRouter := NewRouter(RouterConfig{EnableAutoMethodOptions: true, Middlewares: []Middleware{auth}})
+	_ = apiRouter.Get("/private", principalHandler)
+	_ = mainRouter.Prefix("/api", &apiRouter)
+
+	cases := []struct {
+		router *Router
+		path   string
+	}{
+		{&apiRouter, "/private"},
+		{&mainRouter, "/api/private"},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest("OPTIONS", c.path, nil)
+		res := httptest.NewRecorder()
+		c.router.ServeHTTP(res, req)
+		assertEqual(t, http.StatusNoContent, res.Code)
+
+		req, _ = http.NewRequest("GET", c.path, nil)
+		res = httptest.NewRecorder()
+		c.router.ServeHTTP(res, req)
+		assertEqual(t, http.StatusUnauthorized, res.Code)
+	}
+}
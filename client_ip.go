@@ -0,0 +1,244 @@
+package routing
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ForwardingHeader selects the headers a ClientIPResolver trusts to get the
+// client address and the scheme forwarded by the proxies. Only one kind is
+// trusted, so clients can not spoof the one the proxies do not overwrite.
+type ForwardingHeader int
+
+const (
+	// XForwardedHeaders are the X-Forwarded-For and X-Forwarded-Proto headers
+	XForwardedHeaders ForwardingHeader = iota
+	// ForwardedHeader is the Forwarded header defined in RFC 7239
+	ForwardedHeader
+)
+
+// ClientIPResolver resolves the IP of the client of a request. When the
+// request comes from a trusted proxy, the forwarding headers are walked from
+// the closest hop backwards until the first address that is not a trusted
+// proxy, which is considered the client.
+type ClientIPResolver struct {
+	trusted []*net.IPNet
+	header  ForwardingHeader
+	// bound is the resolver of the router the owner of this one is prefixed
+	// in, which resolves in its place so that routes trust the same proxies
+	// as the router serving them
+	bound *ClientIPResolver
+}
+
+// NewClientIPResolver returns a ClientIPResolver trusting the X-Forwarded-For
+// and X-Forwarded-Proto headers of the proxies whose address is in any of the
+// given IPs or CIDRs. It returns an error if any of them is malformed.
+func NewClientIPResolver(trustedProxies ...string) (*ClientIPResolver, error) {
+	return NewClientIPResolverWithHeader(XForwardedHeaders, trustedProxies...)
+}
+
+// NewClientIPResolverWithHeader returns a ClientIPResolver trusting the given
+// forwarding headers of the proxies whose address is in any of the given IPs
+// or CIDRs. It returns an error if any of them is malformed.
+func NewClientIPResolverWithHeader(header ForwardingHeader, trustedProxies ...string) (*ClientIPResolver, error) {
+	trusted, err := parseCIDRs(trustedProxies...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClientIPResolver{trusted: trusted, header: header}, nil
+}
+
+// ClientIP returns the client IP of a request or nil if it can not be
+// resolved, like when the first untrusted hop is not an IP address
+func (c *ClientIPResolver) ClientIP(r *http.Request) net.IP {
+	if c.bound != nil {
+		return c.bound.ClientIP(r)
+	}
+
+	ip := parseHostIP(r.RemoteAddr)
+	if ip == nil || !c.isTrusted(ip) {
+		return ip
+	}
+
+	hops, _ := c.forwardedHops(r)
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip = parseHostIP(hops[i])
+		if ip == nil || !c.isTrusted(ip) {
+			return ip
+		}
+	}
+
+	return ip
+}
+
+// IsTrusted reports whether the request comes directly from a trusted proxy
+func (c *ClientIPResolver) IsTrusted(r *http.Request) bool {
+	if c.bound != nil {
+		return c.bound.IsTrusted(r)
+	}
+
+	ip := parseHostIP(r.RemoteAddr)
+	return ip != nil && c.isTrusted(ip)
+}
+
+// ForwardedProto returns the scheme of the original request as forwarded by
+// the trusted proxies, or an empty string if the request does not come from a
+// trusted proxy. The schemes are walked from the closest hop backwards while
+// the hops are trusted proxies, so the one of the farthest trusted proxy wins.
+func (c *ClientIPResolver) ForwardedProto(r *http.Request) string {
+	if c.bound != nil {
+		return c.bound.ForwardedProto(r)
+	}
+
+	if !c.IsTrusted(r) {
+		return ""
+	}
+
+	hops, protos := c.forwardedHops(r)
+
+	proto := ""
+	for i, j := len(protos)-1, len(hops)-1; i >= 0; i, j = i-1, j-1 {
+		if protos[i] != "" {
+			proto = protos[i]
+		}
+		if j < 0 {
+			break
+		}
+		if ip := parseHostIP(hops[j]); ip == nil || !c.isTrusted(ip) {
+			break
+		}
+	}
+
+	return strings.ToLower(proto)
+}
+
+func (c *ClientIPResolver) isTrusted(ip net.IP) bool {
+	return containsIP(c.trusted, ip)
+}
+
+// forwardedHops returns the client addresses and the schemes of the trusted
+// forwarding headers, from the farthest to the closest hop
+func (c *ClientIPResolver) forwardedHops(r *http.Request) ([]string, []string) {
+	if c.header == ForwardedHeader {
+		elements := forwardedElements(r)
+		hops := make([]string, len(elements))
+		protos := make([]string, len(elements))
+		for i, forwarded := range elements {
+			hops[i], protos[i] = forwarded["for"], forwarded["proto"]
+		}
+		return hops, protos
+	}
+
+	return headerList(r, "X-Forwarded-For"), headerList(r, "X-Forwarded-Proto")
+}
+
+// headerList returns the comma separated values of all the lines of a header
+func headerList(r *http.Request, name string) []string {
+	var values []string
+	for _, line := range r.Header[name] {
+		for _, v := range strings.Split(line, ",") {
+			values = append(values, strings.TrimSpace(v))
+		}
+	}
+	return values
+}
+
+// forwardedElements parses the Forwarded header defined in RFC 7239 into a
+// list of lowercased parameter maps, one per hop
+func forwardedElements(r *http.Request) []map[string]string {
+	var elements []map[string]string
+
+	for _, line := range r.Header["Forwarded"] {
+		for _, element := range strings.Split(line, ",") {
+			params := make(map[string]string)
+			for _, pair := range strings.Split(element, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) != 2 {
+					continue
+				}
+				params[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+			}
+			elements = append(elements, params)
+		}
+	}
+
+	return elements
+}
+
+// parseHostIP parses an IP optionally followed by a port, like 192.0.2.1:80,
+// [2001:db8::1]:80 or 2001:db8::1
+func parseHostIP(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	return net.ParseIP(strings.Trim(addr, "[]"))
+}
+
+func parseCIDRs(cidrs ...string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %s", cidr)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+type schemeKey int
+
+var forcedSchemeCtxKey schemeKey
+
+// requestScheme returns the scheme of a request, forwarded by a trusted proxy
+// if any, or https for TLS connections and http otherwise. The scheme of the
+// request URL is only used for requests built by clients, as for the server
+// ones it comes from the request line sent by the client.
+func requestScheme(r *http.Request, resolver *ClientIPResolver) string {
+	if scheme, ok := r.Context().Value(forcedSchemeCtxKey).(string); ok {
+		return scheme
+	}
+
+	if resolver != nil {
+		if proto := resolver.ForwardedProto(r); proto != "" {
+			return proto
+		}
+	}
+
+	if r.RequestURI == "" && r.URL.Scheme != "" {
+		return strings.ToLower(r.URL.Scheme)
+	}
+
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,109 @@
+package routing
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNewClientIPResolver_ReturnsErrorWhenMalformedProxy(t *testing.T) {
+	_, err := NewClientIPResolver("10.0.0.0/33")
+	assertNotNil(t, err)
+
+	_, err = NewClientIPResolver("proxy.local")
+	assertNotNil(t, err)
+}
+
+func TestClientIPResolver_ClientIP(t *testing.T) {
+	resolver, err := NewClientIPResolver("10.0.0.0/8", "192.168.1.1", "2001:db8::1")
+	assertNil(t, err)
+
+	forwardedResolver, err := NewClientIPResolverWithHeader(ForwardedHeader, "10.0.0.0/8", "192.168.1.1", "2001:db8::1")
+	assertNil(t, err)
+
+	cases := []struct {
+		resolver                         *ClientIPResolver
+		remote, xff, forwarded, expected string
+	}{
+		{resolver, "203.0.113.1:1234", "", "", "203.0.113.1"},
+		{resolver, "203.0.113.1:1234", "198.51.100.1", "", "203.0.113.1"},
+		{resolver, "10.0.0.1:1234", "", "", "10.0.0.1"},
+		{resolver, "10.0.0.1:1234", "198.51.100.1, 10.0.0.2", "", "198.51.100.1"},
+		{resolver, "10.0.0.1:1234", "6.6.6.6, 198.51.100.1, 192.168.1.1", "", "198.51.100.1"},
+		{resolver, "10.0.0.1:1234", "10.0.0.3, 10.0.0.2", "", "10.0.0.3"},
+		{resolver, "10.0.0.1:1234", "198.51.100.1, garbage", "", ""},
+		{resolver, "10.0.0.1:1234", "198.51.100.1", "for=10.0.0.77", "198.51.100.1"},
+		{forwardedResolver, "10.0.0.1:1234", "10.0.0.77", `for=198.51.100.2;proto=https, for="10.0.0.2:80"`, "198.51.100.2"},
+		{forwardedResolver, "10.0.0.1:1234", "10.0.0.77", "", "10.0.0.1"},
+		{forwardedResolver, "10.0.0.1:1234", "", "for=unknown", ""},
+		{forwardedResolver, "[2001:db8::1]:443", "", `for="[2001:db8:cafe::17]:4711"`, "2001:db8:cafe::17"},
+		{resolver, "unix", "", "", ""},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = c.remote
+		if c.xff != "" {
+			req.Header.Set("X-Forwarded-For", c.xff)
+		}
+		if c.forwarded != "" {
+			req.Header.Set("Forwarded", c.forwarded)
+		}
+
+		ip := c.resolver.ClientIP(req)
+		if c.expected == "" {
+			assertNil(t, ip)
+			continue
+		}
+		assertStringEqual(t, c.expected, ip.String())
+	}
+}
+
+func TestClientIPResolver_IsTrusted(t *testing.T) {
+	resolver, _ := NewClientIPResolver("10.0.0.0/8")
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.1.2.3:80"
+	assertTrue(t, resolver.IsTrusted(req))
+
+	req.RemoteAddr = "11.1.2.3:80"
+	assertFalse(t, resolver.IsTrusted(req))
+}
+
+func TestClientIPResolver_ForwardedProto(t *testing.T) {
+	resolver, _ := NewClientIPResolver("10.0.0.0/8")
+	forwardedResolver, _ := NewClientIPResolverWithHeader(ForwardedHeader, "10.0.0.0/8")
+
+	cases := []struct {
+		resolver                                *ClientIPResolver
+		remote, forwarded, xff, proto, expected string
+	}{
+		{resolver, "203.0.113.1:1234", "", "", "https", ""},
+		{resolver, "10.0.0.1:1234", "", "", "", ""},
+		{resolver, "10.0.0.1:1234", "", "", "HTTPS", "https"},
+		{resolver, "10.0.0.1:1234", "", "", "https, http", "http"},
+		{resolver, "10.0.0.1:1234", "", "198.51.100.1, 10.0.0.2", "https, http", "https"},
+		{resolver, "10.0.0.1:1234", "", "198.51.100.1, 6.6.6.6", "https, http", "http"},
+		{resolver, "10.0.0.1:1234", "for=198.51.100.1;proto=https", "", "http", "http"},
+		{forwardedResolver, "203.0.113.1:1234", "proto=https", "", "", ""},
+		{forwardedResolver, "10.0.0.1:1234", "for=198.51.100.1;proto=https", "", "http", "https"},
+		{forwardedResolver, "10.0.0.1:1234", "for=198.51.100.1;proto=https, for=10.0.0.2;proto=http", "", "", "https"},
+		{forwardedResolver, "10.0.0.1:1234", "for=6.6.6.6;proto=http, for=198.51.100.1;proto=https", "", "", "https"},
+		{forwardedResolver, "10.0.0.1:1234", "for=198.51.100.1", "", "https", ""},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = c.remote
+		if c.forwarded != "" {
+			req.Header.Set("Forwarded", c.forwarded)
+		}
+		if c.xff != "" {
+			req.Header.Set("X-Forwarded-For", c.xff)
+		}
+		if c.proto != "" {
+			req.Header.Set("X-Forwarded-Proto", c.proto)
+		}
+
+		assertStringEqual(t, c.expected, c.resolver.ForwardedProto(req))
+	}
+}
@@ -0,0 +1,312 @@
+package routing
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CompressEncoder is a content coding the Compress middleware can use, like
+// gzip or deflate
+type CompressEncoder struct {
+	// Name is the name of the content coding in the Accept-Encoding and
+	// Content-Encoding headers
+	Name string
+	// New returns a writer compressing into w with the given level
+	New func(w io.Writer, level int) (io.WriteCloser, error)
+}
+
+// CompressOptions configures the Compress middleware
+type CompressOptions struct {
+	// Level is the compression level, like flate.BestSpeed, it defaults to
+	// the default compression level of each encoder when nil. It is a pointer
+	// as 0 is the flate.NoCompression level.
+	Level *int
+	// MinLength is the minimum length of the responses to compress, it
+	// defaults to 1024 bytes. Flushed responses are always compressed.
+	MinLength int
+	// ContentTypes is the list of media types to compress, it defaults to
+	// text, JSON, JavaScript, XML and SVG media types
+	ContentTypes []string
+	// Encoders are the encoders to use besides gzip and deflate, preferred
+	// over them when accepted with the same quality
+	Encoders []CompressEncoder
+}
+
+var defaultCompressContentTypes = []string{
+	"text/*",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/problem+json",
+	"image/svg+xml",
+}
+
+var defaultCompressEncoders = []CompressEncoder{
+	{Name: "gzip", New: func(w io.Writer, level int) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, level)
+	}},
+	{Name: "deflate", New: func(w io.Writer, level int) (io.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	}},
+}
+
+// Compress returns a Middleware compressing the responses with the encoding
+// preferred by the request Accept-Encoding header. Responses already encoded,
+// shorter than the minimum length or whose media type is not listed are sent
+// as they are, like partial ones. It panics if any of the media types is
+// malformed or any of the encoders does not support the level.
+func Compress(options ...CompressOptions) Middleware {
+	opts := CompressOptions{}
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	level := flate.DefaultCompression
+	if opts.Level != nil {
+		level = *opts.Level
+	}
+	if opts.MinLength <= 0 {
+		opts.MinLength = 1024
+	}
+
+	contentTypes := opts.ContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = defaultCompressContentTypes
+	}
+	ranges, err := parseMediaRanges(contentTypes...)
+	if err != nil {
+		panic(err)
+	}
+
+	encoders := append(append([]CompressEncoder{}, opts.Encoders...), defaultCompressEncoders...)
+	names := make([]string, len(encoders))
+	for i, e := range encoders {
+		names[i] = e.Name
+
+		enc, err := e.New(ioutil.Discard, level)
+		if err != nil {
+			panic(fmt.Errorf("compress encoder %s: %v", e.Name, err))
+		}
+		_ = enc.Close()
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r, names)
+			if encoding == "" {
+				next(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				minLength:      opts.MinLength,
+				contentTypes:   ranges,
+				encoding:       encoding,
+				level:          level,
+			}
+			for _, e := range encoders {
+				if e.Name == encoding {
+					cw.encoder = e
+					break
+				}
+			}
+			defer cw.close()
+
+			next(exposeInterfaces(cw), r)
+		}
+	}
+}
+
+// negotiateEncoding returns the encoding among the supported ones with the
+// highest quality in the Accept-Encoding header, or an empty string if none
+// is accepted. Ties are resolved in the order of the supported encodings.
+func negotiateEncoding(r *http.Request, supported []string) string {
+	accepted := make(map[string]float64)
+	for _, line := range r.Header["Accept-Encoding"] {
+		for _, element := range strings.Split(line, ",") {
+			parts := strings.Split(element, ";")
+			name := strings.ToLower(strings.TrimSpace(parts[0]))
+			if name == "" {
+				continue
+			}
+
+			q := 1.0
+			for _, param := range parts[1:] {
+				kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+				if len(kv) == 2 && strings.ToLower(kv[0]) == "q" {
+					if v, err := strconv.ParseFloat(kv[1], 64); err == nil {
+						q = v
+					}
+				}
+			}
+			accepted[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, name := range supported {
+		q, ok := accepted[name]
+		if !ok {
+			q = accepted["*"]
+		}
+		if q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+
+	return best
+}
+
+// compressWriter buffers the beginning of a response to decide whether to
+// compress it, then writes it through the encoder if so
+type compressWriter struct {
+	http.ResponseWriter
+	minLength    int
+	contentTypes []mediaRange
+	encoding     string
+	encoder      CompressEncoder
+	level        int
+
+	buf       bytes.Buffer
+	code      int
+	decided   bool
+	out       io.Writer
+	encWriter io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.code == 0 {
+		cw.code = code
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.code == 0 {
+		cw.code = http.StatusOK
+	}
+
+	if cw.decided {
+		return cw.out.Write(b)
+	}
+
+	cw.buf.Write(b)
+	if cw.buf.Len() >= cw.minLength {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		_ = cw.decide(true)
+	}
+
+	if f, ok := cw.encWriter.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	cw.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	cw.decided, cw.out = true, cw.ResponseWriter
+	return cw.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (cw *compressWriter) Push(target string, opts *http.PushOptions) error {
+	return cw.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// decide sends the headers, compressing the response if it is long enough,
+// not encoded yet and of one of the media types to compress
+func (cw *compressWriter) decide(longEnough bool) error {
+	cw.decided = true
+	cw.out = cw.ResponseWriter
+
+	if cw.code == 0 {
+		cw.code = http.StatusOK
+	}
+
+	header := cw.Header()
+	if header.Get("Content-Type") == "" && cw.buf.Len() > 0 {
+		header.Set("Content-Type", http.DetectContentType(cw.buf.Bytes()))
+	}
+
+	if longEnough && cw.compressible() {
+		enc, err := cw.encoder.New(cw.ResponseWriter, cw.level)
+		if err != nil {
+			return err
+		}
+
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		header.Del("Accept-Ranges")
+		cw.encWriter, cw.out = enc, enc
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.code)
+
+	if cw.buf.Len() > 0 {
+		_, err := cw.out.Write(cw.buf.Bytes())
+		cw.buf.Reset()
+		return err
+	}
+
+	return nil
+}
+
+func (cw *compressWriter) compressible() bool {
+	header := cw.Header()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" ||
+		cw.code < http.StatusOK || cw.code == http.StatusNoContent ||
+		cw.code == http.StatusPartialContent || cw.code == http.StatusNotModified {
+		return false
+	}
+
+	t, err := parseMediaRange(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	for _, c := range cw.contentTypes {
+		if c.includes(t) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if cw.code == 0 && cw.buf.Len() == 0 {
+			return
+		}
+		_ = cw.decide(false)
+	}
+
+	if cw.encWriter != nil {
+		_ = cw.encWriter.Close()
+	}
+}
+
+func (cw *compressWriter) wrapped() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressWriter) wrapper() wrappingWriter {
+	return cw
+}
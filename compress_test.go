@@ -0,0 +1,223 @@
+package routing
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var compressibleBody = strings.Repeat("hello world ", 200)
+
+func writeBodyHandler(contentType, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func gunzip(t *testing.T, b []byte) string {
+	reader, err := gzip.NewReader(bytes.NewReader(b))
+	assertNil(t, err)
+
+	body, err := ioutil.ReadAll(reader)
+	assertNil(t, err)
+
+	return string(body)
+}
+
+func TestCompress_CompressesAcceptedResponses(t *testing.T) {
+	handler := Compress()(writeBodyHandler("text/plain; charset=utf-8", compressibleBody))
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "deflate;q=0.5, gzip")
+	res := httptest.NewRecorder()
+	handler(res, req)
+
+	assertEqual(t, http.StatusOK, res.Code)
+	assertStringEqual(t, "gzip", res.Header().Get("Content-Encoding"))
+	assertStringEqual(t, "Accept-Encoding", res.Header().Get("Vary"))
+	assertStringEqual(t, compressibleBody, gunzip(t, res.Body.Bytes()))
+}
+
+func TestCompress_SkipsResponses(t *testing.T) {
+	cases := []struct {
+		name           string
+		acceptEncoding string
+		handler        http.HandlerFunc
+	}{
+		{"not accepted", "br", writeBodyHandler("text/plain", compressibleBody)},
+		{"rejected", "gzip;q=0, deflate;q=0", writeBodyHandler("text/plain", compressibleBody)},
+		{"small", "gzip", writeBodyHandler("text/plain", "hello")},
+		{"not listed type", "gzip", writeBodyHandler("image/png", compressibleBody)},
+		{"already encoded", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "br")
+			writeBodyHandler("text/plain", compressibleBody)(w, r)
+		}},
+	}
+
+	for _, c := range cases {
+		handler := Compress()(c.handler)
+
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", c.acceptEncoding)
+		res := httptest.NewRecorder()
+		handler(res, req)
+
+		if res.Header().Get("Content-Encoding") == "gzip" {
+			t.Errorf("%s response should not be compressed", c.name)
+		}
+		assertStringEqual(t, "Accept-Encoding", res.Header().Get("Vary"))
+	}
+}
+
+func TestCompress_UsesPluggableEncodersAndSniffedTypes(t *testing.T) {
+	var used bool
+	encoder := CompressEncoder{Name: "x-test", New: func(w io.Writer, level int) (io.WriteCloser, error) {
+		used = true
+		return gzip.NewWriterLevel(w, level)
+	}}
+
+	handler := Compress(CompressOptions{
+		Encoders:     []CompressEncoder{encoder},
+		ContentTypes: []string{"text/html"},
+		MinLength:    10,
+	})(writeBodyHandler("", "<html><body>"+compressibleBody+"</body></html>"))
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip, x-test")
+	res := httptest.NewRecorder()
+	handler(res, req)
+
+	assertTrue(t, used)
+	assertStringEqual(t, "x-test", res.Header().Get("Content-Encoding"))
+	assertStringContains(t, "text/html", res.Header().Get("Content-Type"))
+}
+
+func TestCompress_CompressesFlushedResponses(t *testing.T) {
+	handler := Compress()(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res := httptest.NewRecorder()
+	handler(res, req)
+
+	assertTrue(t, res.Flushed)
+	assertStringEqual(t, "gzip", res.Header().Get("Content-Encoding"))
+	assertStringEqual(t, "data: 1\n\n", gunzip(t, res.Body.Bytes()))
+}
+
+func TestRouter_StaticFiles_ServesPrecompressedFiles(t *testing.T) {
+	mainRouter := Router{}
+	_ = mainRouter.StaticFiles("/assets", "./fixtures/precompressed", StaticFilesOptions{Precompressed: []string{"br", "gzip"}})
+
+	req, _ := http.NewRequest("GET", "/assets/app.js", nil)
+	req.Header.Set("Accept-Encoding", "br, gzip")
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+
+	assertEqual(t, http.StatusOK, res.Code)
+	assertStringEqual(t, "gzip", res.Header().Get("Content-Encoding"))
+	assertStringContains(t, "javascript", res.Header().Get("Content-Type"))
+	assertStringEqual(t, "console.log(\"plain\");\n", gunzip(t, res.Body.Bytes()))
+
+	req.Header.Del("Accept-Encoding")
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+
+	assertEqual(t, http.StatusOK, res.Code)
+	assertStringEqual(t, "", res.Header().Get("Content-Encoding"))
+	assertStringEqual(t, "console.log(\"plain\");\n", res.Body.String())
+}
+
+func TestCompress_UsesTheGivenLevel(t *testing.T) {
+	var levels []int
+	encoder := CompressEncoder{Name: "x-test", New: func(w io.Writer, level int) (io.WriteCloser, error) {
+		levels = append(levels, level)
+		return gzip.NewWriterLevel(w, level)
+	}}
+
+	noCompression := flate.NoCompression
+	for _, options := range []CompressOptions{
+		{Encoders: []CompressEncoder{encoder}, MinLength: 10},
+		{Encoders: []CompressEncoder{encoder}, MinLength: 10, Level: &noCompression},
+	} {
+		handler := Compress(options)(writeBodyHandler("text/plain", compressibleBody))
+
+		levels = nil
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", "x-test")
+		handler(httptest.NewRecorder(), req)
+
+		expected := flate.DefaultCompression
+		if options.Level != nil {
+			expected = *options.Level
+		}
+		assertEqual(t, 1, len(levels))
+		assertEqual(t, expected, levels[0])
+	}
+}
+
+func TestCompress_PanicsWhenLevelIsInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("panic expected when level is invalid")
+		}
+	}()
+
+	level := 42
+	Compress(CompressOptions{Level: &level})
+}
+
+func TestCompress_SkipsPartialResponses(t *testing.T) {
+	handler := Compress()(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		http.ServeContent(w, r, "hello.txt", time.Time{}, strings.NewReader(compressibleBody))
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Range", "bytes=0-1999")
+	res := httptest.NewRecorder()
+	handler(res, req)
+
+	assertEqual(t, http.StatusPartialContent, res.Code)
+	assertStringEqual(t, "", res.Header().Get("Content-Encoding"))
+	assertStringEqual(t, compressibleBody[:2000], res.Body.String())
+
+	req.Header.Del("Range")
+	res = httptest.NewRecorder()
+	handler(res, req)
+
+	assertEqual(t, http.StatusOK, res.Code)
+	assertStringEqual(t, "gzip", res.Header().Get("Content-Encoding"))
+	assertStringEqual(t, "", res.Header().Get("Accept-Ranges"))
+	assertStringEqual(t, compressibleBody, gunzip(t, res.Body.Bytes()))
+}
+
+func TestCompress_ExposesOnlyInterfacesOfWrappedWriter(t *testing.T) {
+	handler := Compress()(func(w http.ResponseWriter, r *http.Request) {
+		_, isFlusher := w.(http.Flusher)
+		_, isHijacker := w.(http.Hijacker)
+		_, isPusher := w.(http.Pusher)
+		assertTrue(t, isFlusher)
+		assertFalse(t, isHijacker)
+		assertFalse(t, isPusher)
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	handler(httptest.NewRecorder(), req)
+}
@@ -0,0 +1,216 @@
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSPolicy configures the Cross-Origin Resource Sharing of the routes of a
+// Router or of a single route. The router answers the preflight requests of
+// the routes with a policy and adds the CORS headers to their responses.
+type CORSPolicy struct {
+	// AllowedOrigins is the list of origins allowed, like https://example.com,
+	// https://*.example.com for any of its subdomains or * for any origin
+	AllowedOrigins []string
+	// AllowedMethods is the list of methods allowed, it defaults to the
+	// methods of the routes registered for the requested path
+	AllowedMethods []string
+	// AllowedHeaders is the list of request headers allowed, * allows any of
+	// them. Only CORS-safelisted headers are allowed when empty.
+	AllowedHeaders []string
+	// ExposedHeaders is the list of response headers exposed to the client
+	ExposedHeaders []string
+	// AllowCredentials allows the requests with credentials
+	AllowCredentials bool
+	// MaxAge is how long the result of a preflight request can be cached
+	MaxAge time.Duration
+}
+
+// corsPolicy is the compiled form of a CORSPolicy
+type corsPolicy struct {
+	anyOrigin      bool
+	origins        map[string]bool
+	wildcards      [][2]string
+	methods        map[string]bool
+	anyHeader      bool
+	headers        map[string]bool
+	allowedMethods string
+	allowedHeaders string
+	exposedHeaders string
+	credentials    bool
+	maxAge         string
+}
+
+func newCORSPolicy(p *CORSPolicy) (*corsPolicy, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	c := &corsPolicy{
+		origins:        make(map[string]bool),
+		methods:        make(map[string]bool),
+		headers:        make(map[string]bool),
+		allowedMethods: strings.Join(p.AllowedMethods, ", "),
+		exposedHeaders: strings.Join(p.ExposedHeaders, ", "),
+		credentials:    p.AllowCredentials,
+	}
+
+	for _, origin := range p.AllowedOrigins {
+		origin = strings.ToLower(origin)
+		switch strings.Count(origin, "*") {
+		case 0:
+			c.origins[origin] = true
+		case 1:
+			if origin == "*" {
+				c.anyOrigin = true
+				continue
+			}
+			i := strings.IndexByte(origin, '*')
+			c.wildcards = append(c.wildcards, [2]string{origin[:i], origin[i+1:]})
+		default:
+			return nil, fmt.Errorf("invalid CORS origin %s", origin)
+		}
+	}
+
+	for _, method := range p.AllowedMethods {
+		c.methods[strings.ToUpper(method)] = true
+	}
+
+	headers := make([]string, 0, len(p.AllowedHeaders))
+	for _, header := range p.AllowedHeaders {
+		if header == "*" {
+			c.anyHeader = true
+			continue
+		}
+		header = http.CanonicalHeaderKey(header)
+		c.headers[header] = true
+		headers = append(headers, header)
+	}
+	c.allowedHeaders = strings.Join(headers, ", ")
+
+	if p.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(p.MaxAge / time.Second))
+	}
+
+	return c, nil
+}
+
+func (c *corsPolicy) allowsOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if c.origins[origin] {
+		return true
+	}
+
+	for _, w := range c.wildcards {
+		if len(origin) > len(w[0])+len(w[1]) && strings.HasPrefix(origin, w[0]) && strings.HasSuffix(origin, w[1]) {
+			if !strings.ContainsAny(origin[len(w[0]):len(origin)-len(w[1])], "/:") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// requestedHeaders returns the headers of a preflight request if all of them
+// are allowed
+func (c *corsPolicy) requestedHeaders(r *http.Request) (string, bool) {
+	var requested []string
+	for _, line := range r.Header["Access-Control-Request-Headers"] {
+		for _, header := range strings.Split(line, ",") {
+			if header = strings.TrimSpace(header); header != "" {
+				requested = append(requested, http.CanonicalHeaderKey(header))
+			}
+		}
+	}
+
+	if c.anyHeader {
+		return strings.Join(requested, ", "), true
+	}
+
+	for _, header := range requested {
+		if !c.headers[header] {
+			return "", false
+		}
+	}
+
+	return c.allowedHeaders, true
+}
+
+// allowOrigin sets the headers allowing the origin of the request, if any
+func (c *corsPolicy) allowOrigin(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+
+	w.Header().Add("Vary", "Origin")
+	if !c.allowsOrigin(origin) {
+		return false
+	}
+
+	if c.anyOrigin && !c.credentials {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if c.credentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	return true
+}
+
+// decorate adds the CORS headers to the response of an actual request
+func (c *corsPolicy) decorate(w http.ResponseWriter, r *http.Request) {
+	if c.allowOrigin(w, r) && c.exposedHeaders != "" {
+		w.Header().Set("Access-Control-Expose-Headers", c.exposedHeaders)
+	}
+}
+
+// preflight answers a preflight request for the given methods available for
+// the requested path, leaving out the CORS headers if it is not allowed
+func (c *corsPolicy) preflight(w http.ResponseWriter, r *http.Request, available []string) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	method := r.Header.Get("Access-Control-Request-Method")
+	headers, allowed := c.requestedHeaders(r)
+	if len(c.methods) > 0 && !c.methods[method] {
+		allowed = false
+	}
+
+	if allowed && c.allowOrigin(w, r) {
+		methods := c.allowedMethods
+		if methods == "" {
+			sort.Strings(available)
+			methods = strings.Join(available, ", ")
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", methods)
+		if headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+		if c.maxAge != "" {
+			w.Header().Set("Access-Control-Max-Age", c.maxAge)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// isPreflight checks if the request is a CORS preflight request
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
@@ -0,0 +1,121 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newPreflightRequest(path, origin, method, headers string) *http.Request {
+	req, _ := http.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	return req
+}
+
+func TestRouter_CORS_AnswersPreflightRequests(t *testing.T) {
+	mainRouter := NewRouter(RouterConfig{CORS: &CORSPolicy{
+		AllowedOrigins:   []string{"https://example.com", "https://*.example.org"},
+		AllowedHeaders:   []string{"Content-Type", "x-api-key"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}})
+
+	_ = mainRouter.Get("/users", testHandlerFunc)
+	_ = mainRouter.Delete("/users", testHandlerFunc)
+
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, newPreflightRequest("/users", "https://api.example.org", "DELETE", "content-type, X-Api-Key"))
+
+	headers := res.Result().Header
+	assertEqual(t, http.StatusNoContent, res.Code)
+	assertStringEqual(t, "https://api.example.org", headers.Get("Access-Control-Allow-Origin"))
+	assertStringEqual(t, "DELETE, GET", headers.Get("Access-Control-Allow-Methods"))
+	assertStringEqual(t, "Content-Type, X-Api-Key", headers.Get("Access-Control-Allow-Headers"))
+	assertStringEqual(t, "true", headers.Get("Access-Control-Allow-Credentials"))
+	assertStringEqual(t, "600", headers.Get("Access-Control-Max-Age"))
+
+	cases := []struct {
+		origin, method, headers string
+	}{
+		{"https://evil.com", "GET", ""},
+		{"https://example.org", "GET", ""},
+		{"https://a.example.org.evil.com", "GET", ""},
+		{"https://example.com", "GET", "X-Other"},
+	}
+
+	for _, c := range cases {
+		res = httptest.NewRecorder()
+		mainRouter.ServeHTTP(res, newPreflightRequest("/users", c.origin, c.method, c.headers))
+
+		assertEqual(t, http.StatusNoContent, res.Code)
+		assertStringEqual(t, "", res.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, newPreflightRequest("/users", "https://example.com", "PUT", ""))
+	assertEqual(t, http.StatusNotFound, res.Code)
+}
+
+func TestRouter_CORS_DecoratesActualResponses(t *testing.T) {
+	mainRouter := NewRouter(RouterConfig{CORS: &CORSPolicy{
+		AllowedOrigins: []string{"*"},
+		ExposedHeaders: []string{"X-Total-Count"},
+	}})
+
+	_ = mainRouter.Get("/users", testHandlerFunc)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Origin", "https://example.com")
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+
+	headers := res.Result().Header
+	assertEqual(t, http.StatusOK, res.Code)
+	assertStringEqual(t, "*", headers.Get("Access-Control-Allow-Origin"))
+	assertStringEqual(t, "X-Total-Count", headers.Get("Access-Control-Expose-Headers"))
+	assertStringEqual(t, "Origin", headers.Get("Vary"))
+
+	req.Header.Del("Origin")
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertStringEqual(t, "", res.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestRouter_CORS_AppliesRouteAndGroupPolicies(t *testing.T) {
+	mainRouter := NewRouter(RouterConfig{CORS: &CORSPolicy{AllowedOrigins: []string{"https://example.com"}}})
+	apiRouter := NewRouter(RouterConfig{CORS: &CORSPolicy{AllowedOrigins: []string{"https://api.com"}, AllowedMethods: []string{"GET"}}})
+	adminRouter := Router{}
+
+	_ = mainRouter.Get("/public", testHandlerFunc, MatchingOptions{CORS: &CORSPolicy{AllowedOrigins: []string{"*"}}})
+	_ = apiRouter.Get("/users", testHandlerFunc)
+	_ = adminRouter.Get("/users", testHandlerFunc)
+	_ = mainRouter.Prefix("/api", &apiRouter)
+	_ = mainRouter.Prefix("/admin", &adminRouter)
+
+	cases := []struct {
+		path, origin, method, allowed string
+	}{
+		{"/public", "https://other.com", "GET", "*"},
+		{"/api/users", "https://api.com", "GET", "https://api.com"},
+		{"/api/users", "https://example.com", "GET", ""},
+		{"/admin/users", "https://example.com", "GET", "https://example.com"},
+	}
+
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		mainRouter.ServeHTTP(res, newPreflightRequest(c.path, c.origin, c.method, ""))
+		assertStringEqual(t, c.allowed, res.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestRouter_CORS_ReturnsErrorWhenMalformedOrigin(t *testing.T) {
+	mainRouter := Router{}
+
+	err := mainRouter.Get("/users", testHandlerFunc, MatchingOptions{CORS: &CORSPolicy{AllowedOrigins: []string{"https://*.*.com"}}})
+	assertNotNil(t, err)
+}
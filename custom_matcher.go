@@ -0,0 +1,148 @@
+package routing
+
+import "net/http"
+
+// AllOf returns a CustomMatcher matching a request when all the given matchers
+// match it. It matches any request when no matchers are given.
+func AllOf(matchers ...CustomMatcher) CustomMatcher {
+	return func(r *http.Request) bool {
+		for _, m := range matchers {
+			if !m(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AnyOf returns a CustomMatcher matching a request when at least one of the
+// given matchers matches it. It matches no request when no matchers are given.
+func AnyOf(matchers ...CustomMatcher) CustomMatcher {
+	return func(r *http.Request) bool {
+		for _, m := range matchers {
+			if m(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a CustomMatcher matching a request when the given one does not
+func Not(m CustomMatcher) CustomMatcher {
+	return func(r *http.Request) bool {
+		return !m(r)
+	}
+}
+
+// ByHeader returns a CustomMatcher matching a request header value against a
+// value pattern, like MatchingOptions.Headers does. Captured values are not
+// available through GetURLParameters. It returns an error if the pattern is
+// malformed.
+func ByHeader(name, value string) (CustomMatcher, error) {
+	m, err := byHeaders(map[string]string{name: value})
+	if err != nil {
+		return nil, err
+	}
+
+	return toCustomMatcher(m.match), nil
+}
+
+// MustByHeader is like ByHeader but panics if the pattern is malformed
+func MustByHeader(name, value string) CustomMatcher {
+	return mustCustomMatcher(ByHeader(name, value))
+}
+
+// ByQuery returns a CustomMatcher matching a request query parameter value
+// against a value pattern, like MatchingOptions.QueryParams does. Captured
+// values are not available through GetURLParameters. It returns an error if
+// the pattern is malformed.
+func ByQuery(name, value string) (CustomMatcher, error) {
+	m, err := byQueryParameters(map[string]string{name: value})
+	if err != nil {
+		return nil, err
+	}
+
+	return toCustomMatcher(m.match), nil
+}
+
+// MustByQuery is like ByQuery but panics if the pattern is malformed
+func MustByQuery(name, value string) CustomMatcher {
+	return mustCustomMatcher(ByQuery(name, value))
+}
+
+// ByHost returns a CustomMatcher matching the request host against a host
+// pattern, like MatchingOptions.Host does. Captured values are not available
+// through GetURLParameters. It returns an error if the pattern is malformed.
+func ByHost(host string) (CustomMatcher, error) {
+	m, err := byHost(host)
+	if err != nil {
+		return nil, err
+	}
+
+	return toCustomMatcher(m.match), nil
+}
+
+// MustByHost is like ByHost but panics if the pattern is malformed
+func MustByHost(host string) CustomMatcher {
+	return mustCustomMatcher(ByHost(host))
+}
+
+// ByScheme returns a CustomMatcher matching the request scheme against any of
+// the given ones, like MatchingOptions.Schemas does. The forwarding headers of
+// the proxies trusted by the given resolver are honored, so it agrees with the
+// routes of a router when given a resolver with the same trusted proxies as
+// RouterConfig.TrustedProxies. A nil resolver trusts no proxy. It returns an
+// error if any of the schemes is malformed.
+func ByScheme(resolver *ClientIPResolver, schemes ...string) (CustomMatcher, error) {
+	m, err := bySchemas(resolver, schemes...)
+	if err != nil {
+		return nil, err
+	}
+
+	return toCustomMatcher(m), nil
+}
+
+// MustByScheme is like ByScheme but panics if any of the schemes is malformed
+func MustByScheme(resolver *ClientIPResolver, schemes ...string) CustomMatcher {
+	return mustCustomMatcher(ByScheme(resolver, schemes...))
+}
+
+// ByPath returns a CustomMatcher matching the request path against any of the
+// given path patterns, with the {name:regexp} syntax of the routes, like
+// /health or /metrics/{name}. It returns an error if any of the patterns is
+// malformed.
+func ByPath(patterns ...string) (CustomMatcher, error) {
+	t := &tree{}
+	for _, pattern := range patterns {
+		parser := newParser(pattern)
+		if _, err := parser.parse(); err != nil {
+			return nil, err
+		}
+		t.insert(parser.chunks, func(w http.ResponseWriter, r *http.Request) {})
+	}
+
+	return func(r *http.Request) bool {
+		return find(t.root, r.URL.Path, r) != nil
+	}, nil
+}
+
+// MustByPath is like ByPath but panics if any of the patterns is malformed
+func MustByPath(patterns ...string) CustomMatcher {
+	return mustCustomMatcher(ByPath(patterns...))
+}
+
+func mustCustomMatcher(m CustomMatcher, err error) CustomMatcher {
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
+
+func toCustomMatcher(m matcher) CustomMatcher {
+	return func(r *http.Request) bool {
+		matches, _ := m(r)
+		return matches
+	}
+}
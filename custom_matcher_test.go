@@ -0,0 +1,146 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var (
+	testTrueMatcher  CustomMatcher = func(r *http.Request) bool { return true }
+	testFalseMatcher CustomMatcher = func(r *http.Request) bool { return false }
+)
+
+func TestAllOf(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+
+	assertTrue(t, AllOf()(req))
+	assertTrue(t, AllOf(testTrueMatcher, testTrueMatcher)(req))
+	assertFalse(t, AllOf(testTrueMatcher, testFalseMatcher)(req))
+}
+
+func TestAnyOf(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+
+	assertFalse(t, AnyOf()(req))
+	assertTrue(t, AnyOf(testFalseMatcher, testTrueMatcher)(req))
+	assertFalse(t, AnyOf(testFalseMatcher, testFalseMatcher)(req))
+}
+
+func TestNot(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+
+	assertFalse(t, Not(testTrueMatcher)(req))
+	assertTrue(t, Not(testFalseMatcher)(req))
+}
+
+func TestMustByHeader(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Client", "mobile-ios")
+
+	assertTrue(t, MustByHeader("X-Client", "mobile-{os}")(req))
+	assertTrue(t, MustByHeader("X-Client", "")(req))
+	assertFalse(t, MustByHeader("X-Client", "desktop")(req))
+	assertFalse(t, MustByHeader("X-Other", "")(req))
+}
+
+func TestMustByQuery(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/?beta=1", nil)
+
+	assertTrue(t, MustByQuery("beta", "{_:[01]}")(req))
+	assertFalse(t, MustByQuery("beta", "0")(req))
+}
+
+func TestMustByHost(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Host = "app.internal.test.com"
+
+	assertTrue(t, MustByHost("app.{env:[a-z]+}.test.com")(req))
+	assertFalse(t, MustByHost("app.test.com")(req))
+}
+
+func TestMustByScheme(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.URL.Scheme = "https"
+
+	assertTrue(t, MustByScheme(nil, "http", "https")(req))
+	assertFalse(t, MustByScheme(nil, "ftp")(req))
+}
+
+func TestMustByScheme_HonorsTrustedProxies(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	resolver, err := NewClientIPResolver("10.0.0.0/8")
+	assertNil(t, err)
+
+	assertTrue(t, MustByScheme(resolver, "https")(req))
+	assertFalse(t, MustByScheme(nil, "https")(req))
+
+	untrusted, err := NewClientIPResolver("192.168.0.0/16")
+	assertNil(t, err)
+
+	assertFalse(t, MustByScheme(untrusted, "https")(req))
+}
+
+func TestByMatchers_ReturnErrorsWhenPatternsAreMalformed(t *testing.T) {
+	_, err := ByHeader("Accept", "v{version")
+	assertNotNil(t, err)
+	_, err = ByQuery("version", "v{version")
+	assertNotNil(t, err)
+	_, err = ByHost("{sub.test.com")
+	assertNotNil(t, err)
+	_, err = ByScheme(nil, "http{s")
+	assertNotNil(t, err)
+	_, err = ByPath("/users/{id")
+	assertNotNil(t, err)
+
+	m, err := ByPath("/users/{id}")
+	assertNil(t, err)
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	assertTrue(t, m(req))
+}
+
+func TestMustByHeader_PanicsWhenPatternIsMalformed(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("panic expected when pattern is malformed")
+		}
+	}()
+
+	MustByHeader("Accept", "v{version")
+}
+
+func TestRouter_MatchingOptions_MatchesByComposedMatchers(t *testing.T) {
+	mainRouter := Router{}
+
+	options := NewMatchingOptions()
+	options.Custom = AllOf(
+		AnyOf(MustByHeader("X-Client", "mobile"), MustByQuery("beta", "1")),
+		Not(MustByHost("{_:.*}.internal.com")),
+	)
+	_ = mainRouter.Get("/feature", testHandlerFunc, options)
+
+	cases := []struct {
+		host, query, client string
+		code                int
+	}{
+		{"public.com", "", "mobile", http.StatusOK},
+		{"public.com", "?beta=1", "", http.StatusOK},
+		{"public.com", "", "", http.StatusNotFound},
+		{"app.internal.com", "?beta=1", "mobile", http.StatusNotFound},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/feature"+c.query, nil)
+		req.Host = c.host
+		if c.client != "" {
+			req.Header.Set("X-Client", c.client)
+		}
+		res := httptest.NewRecorder()
+		mainRouter.ServeHTTP(res, req)
+
+		assertEqual(t, c.code, res.Code)
+	}
+}
@@ -0,0 +1,136 @@
+package routing
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// HandlerE is an http handler returning an error, which is responded by the
+// ErrorHandler of the router of the route matched, or by DefaultErrorHandler
+type HandlerE func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP calls the handler, responding the error returned if any
+func (h HandlerE) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h(w, r); err != nil {
+		handleError(w, r, err)
+	}
+}
+
+// MiddlewareE is a Middleware for handlers returning errors, it can stop the
+// request by returning an error without calling the next handler
+type MiddlewareE func(HandlerE) HandlerE
+
+// Middleware returns the MiddlewareE as a Middleware, the errors returned are
+// responded by the ErrorHandler as the ones returned by a HandlerE
+func (m MiddlewareE) Middleware() Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return m(func(w http.ResponseWriter, r *http.Request) error {
+			next(w, r)
+			return nil
+		}).ServeHTTP
+	}
+}
+
+// NextE adds error aware middlewares to the pipe
+func (s *MiddlewarePipe) NextE(middleware ...MiddlewareE) *MiddlewarePipe {
+	for _, m := range middleware {
+		s.Next(m.Middleware())
+	}
+	return s
+}
+
+// ErrorHandler responds the errors returned by a HandlerE or a MiddlewareE
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// HTTPError is an error with the response status code, an application error
+// code and details to respond with
+type HTTPError struct {
+	Status  int         `json:"-"`
+	Code    string      `json:"code,omitempty"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+	Err     error       `json:"-"`
+}
+
+// NewHTTPError returns an HTTPError with the given status code and message,
+// the status text is used when the message is empty. Status codes other than
+// client or server error ones default to 500 Internal Server Error.
+func NewHTTPError(status int, message string) *HTTPError {
+	status = validStatus(status)
+	if message == "" {
+		message = strings.ToLower(http.StatusText(status))
+	}
+	return &HTTPError{Status: status, Message: message}
+}
+
+func (e *HTTPError) Error() string {
+	msg := strconv.Itoa(e.status()) + " " + e.Message
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// status returns the status code to respond the error with, 500 Internal
+// Server Error if it is not a client or server error one
+func (e *HTTPError) status() int {
+	return validStatus(e.Status)
+}
+
+func validStatus(status int) int {
+	if status < http.StatusBadRequest || status > 599 {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
+// Unwrap returns the error causing the HTTPError, if any
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// DefaultErrorHandler responds HTTPErrors with their status code, as JSON if
+// the request accepts it or as plain text otherwise. Any other error is
+// responded with a 500 Internal Server Error without revealing it, like the
+// HTTPErrors without a client or server error status code.
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		httpErr = NewHTTPError(http.StatusInternalServerError, "")
+	}
+
+	jsonType, _ := parseMediaRange("application/json")
+	if len(parseAccept(r)) > 0 && bestQuality(r, []mediaRange{jsonType}) > 0 {
+		writeJSONError(w, httpErr)
+		return
+	}
+
+	status := httpErr.status()
+	message := httpErr.Message
+	if message == "" {
+		message = strings.ToLower(http.StatusText(status))
+	}
+
+	http.Error(w, strconv.Itoa(status)+" "+message, status)
+}
+
+func writeJSONError(w http.ResponseWriter, err *HTTPError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(err.status())
+	_ = json.NewEncoder(w).Encode(err)
+}
+
+// handleError responds an error with the ErrorHandler of the route matched by
+// the request, or with DefaultErrorHandler
+func handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if leaf := matchedRoute(r); leaf != nil && leaf.errorHandler != nil {
+		leaf.errorHandler(w, r, err)
+		return
+	}
+
+	DefaultErrorHandler(w, r, err)
+}
@@ -0,0 +1,157 @@
+package routing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefaultErrorHandler(t *testing.T) {
+	notFound := &HTTPError{Status: http.StatusNotFound, Code: "user_not_found", Message: "user not found", Details: map[string]string{"id": "1"}}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	DefaultErrorHandler(res, req, fmt.Errorf("loading user: %w", notFound))
+	assertEqual(t, http.StatusNotFound, res.Code)
+	assertStringEqual(t, "404 user not found\n", res.Body.String())
+
+	req.Header.Set("Accept", "application/json")
+	res = httptest.NewRecorder()
+	DefaultErrorHandler(res, req, notFound)
+	assertEqual(t, http.StatusNotFound, res.Code)
+	assertStringEqual(t, "application/json; charset=utf-8", res.Header().Get("Content-Type"))
+
+	var body map[string]interface{}
+	assertNil(t, json.Unmarshal(res.Body.Bytes(), &body))
+	assertStringEqual(t, "user_not_found", body["code"].(string))
+	assertStringEqual(t, "user not found", body["message"].(string))
+	assertStringEqual(t, "1", body["details"].(map[string]interface{})["id"].(string))
+
+	req.Header.Del("Accept")
+	res = httptest.NewRecorder()
+	DefaultErrorHandler(res, req, errors.New("database password leaked"))
+	assertEqual(t, http.StatusInternalServerError, res.Code)
+	assertStringEqual(t, "500 internal server error\n", res.Body.String())
+}
+
+func TestHTTPError_WrapsErrors(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := &HTTPError{Status: http.StatusBadGateway, Message: "bad gateway", Err: cause}
+
+	assertStringEqual(t, "502 bad gateway: connection refused", err.Error())
+	assertTrue(t, errors.Is(err, cause))
+	assertStringEqual(t, "conflict", NewHTTPError(http.StatusConflict, "").Message)
+}
+
+func TestDefaultErrorHandler_RespondsInvalidStatusCodesAsInternalServerError(t *testing.T) {
+	mainRouter := Router{}
+	_ = mainRouter.GetE("/text", func(w http.ResponseWriter, r *http.Request) error {
+		return &HTTPError{Code: "bad"}
+	})
+	_ = mainRouter.GetE("/json", func(w http.ResponseWriter, r *http.Request) error {
+		return &HTTPError{Status: 42, Code: "bad"}
+	})
+	_ = mainRouter.GetE("/informational", func(w http.ResponseWriter, r *http.Request) error {
+		return &HTTPError{Status: http.StatusSwitchingProtocols}
+	})
+
+	server := httptest.NewServer(&mainRouter)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/text")
+	assertNil(t, err)
+	body, _ := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+	assertEqual(t, http.StatusInternalServerError, res.StatusCode)
+	assertStringEqual(t, "500 internal server error\n", string(body))
+
+	req, _ := http.NewRequest("GET", server.URL+"/json", nil)
+	req.Header.Set("Accept", "application/json")
+	res, err = http.DefaultClient.Do(req)
+	assertNil(t, err)
+	_ = res.Body.Close()
+	assertEqual(t, http.StatusInternalServerError, res.StatusCode)
+
+	res, err = http.Get(server.URL + "/informational")
+	assertNil(t, err)
+	_ = res.Body.Close()
+	assertEqual(t, http.StatusInternalServerError, res.StatusCode)
+
+	assertEqual(t, http.StatusInternalServerError, NewHTTPError(0, "").Status)
+	assertEqual(t, http.StatusInternalServerError, NewHTTPError(http.StatusFound, "").Status)
+	assertEqual(t, http.StatusTeapot, NewHTTPError(http.StatusTeapot, "").Status)
+}
+
+func TestRouter_RegisterE_RespondsErrorsWithErrorHandler(t *testing.T) {
+	var handled error
+	mainRouter := NewRouter(RouterConfig{ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+		handled = err
+		w.WriteHeader(http.StatusTeapot)
+	}})
+	defaultRouter := Router{}
+
+	failing := func(w http.ResponseWriter, r *http.Request) error {
+		return NewHTTPError(http.StatusForbidden, "")
+	}
+	_ = mainRouter.GetE("/fail", failing)
+	_ = mainRouter.PostE("/ok", func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusCreated)
+		return nil
+	})
+	_ = defaultRouter.GetE("/fail", failing)
+	_ = mainRouter.Prefix("/default", &defaultRouter)
+
+	req, _ := http.NewRequest("GET", "/fail", nil)
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusTeapot, res.Code)
+	assertStringEqual(t, "403 forbidden", handled.Error())
+
+	req, _ = http.NewRequest("POST", "/ok", nil)
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusCreated, res.Code)
+
+	req, _ = http.NewRequest("GET", "/default/fail", nil)
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusTeapot, res.Code)
+
+	handled = nil
+	otherRouter := Router{}
+	_ = otherRouter.GetE("/fail", failing)
+	req, _ = http.NewRequest("GET", "/fail", nil)
+	res = httptest.NewRecorder()
+	otherRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusForbidden, res.Code)
+	assertNil(t, handled)
+}
+
+func TestMiddlewareE_ShortCircuitsWithErrors(t *testing.T) {
+	requireHeader := MiddlewareE(func(next HandlerE) HandlerE {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			if r.Header.Get("X-Token") == "" {
+				return NewHTTPError(http.StatusUnauthorized, "missing token")
+			}
+			return next(w, r)
+		}
+	})
+
+	mainRouter := Router{}
+	_ = mainRouter.Get("/users", NewMiddlewarePipe().NextE(requireHeader).Then(testHandlerFunc))
+
+	req, _ := http.NewRequest("GET", "/users", nil)
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusUnauthorized, res.Code)
+	assertStringEqual(t, "401 missing token\n", res.Body.String())
+
+	req.Header.Set("X-Token", "token")
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusOK, res.Code)
+}
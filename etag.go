@@ -0,0 +1,301 @@
+package routing
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETagOptions configures the ETag middleware
+type ETagOptions struct {
+	// Weak generates weak ETags, for responses whose bytes may change without
+	// changing their meaning, like re-encoded JSON documents
+	Weak bool
+	// MaxSize is the maximum size of the responses to buffer to generate their
+	// ETag, it defaults to 1 MiB. Longer or flushed responses are sent as they
+	// are, without ETag nor conditional request evaluation.
+	MaxSize int
+}
+
+// ETag returns a Middleware buffering the 200 OK responses of GET and HEAD
+// requests to tag them with a hash of their body, unless the handler sets its
+// own ETag, and answering conditional requests. Requests whose If-Match does
+// not match the ETag get a 412 Precondition Failed, the ones whose
+// If-None-Match matches it, or without If-None-Match whose If-Modified-Since is
+// not older than the Last-Modified header, get a 304 Not Modified. Routes
+// registered with MatchingOptions.DisableETag are sent as they are. Used along
+// Compress, ETag must wrap it so each encoding gets its own ETag.
+func ETag(options ...ETagOptions) Middleware {
+	opts := ETagOptions{}
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 1 << 20
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next(w, r)
+				return
+			}
+
+			r, _ = withRequestSlot(r)
+			ew := &etagWriter{ResponseWriter: w, request: r, weak: opts.Weak, maxSize: opts.MaxSize}
+
+			next(exposeInterfaces(ew), r)
+			ew.close()
+		}
+	}
+}
+
+// etagWriter buffers a response to tag it, unless it is too long, flushed or
+// hijacked, in which case it is streamed as it is
+type etagWriter struct {
+	http.ResponseWriter
+	request *http.Request
+	weak    bool
+	maxSize int
+
+	buf       bytes.Buffer
+	code      int
+	streaming bool
+}
+
+func (ew *etagWriter) WriteHeader(code int) {
+	if ew.code != 0 {
+		return
+	}
+
+	ew.code = code
+	if ew.disabled() {
+		ew.stream()
+	}
+}
+
+// disabled reports whether the route matched by the request opted out of
+// ETags
+func (ew *etagWriter) disabled() bool {
+	leaf := matchedRoute(ew.request)
+	return leaf != nil && leaf.noETag
+}
+
+func (ew *etagWriter) Write(b []byte) (int, error) {
+	if ew.code == 0 {
+		ew.WriteHeader(http.StatusOK)
+	}
+
+	if ew.streaming {
+		return ew.ResponseWriter.Write(b)
+	}
+
+	ew.buf.Write(b)
+	if ew.buf.Len() > ew.maxSize {
+		if err := ew.stream(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+func (ew *etagWriter) Flush() {
+	if !ew.streaming {
+		_ = ew.stream()
+	}
+
+	ew.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (ew *etagWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	ew.streaming = true
+	return ew.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (ew *etagWriter) Push(target string, opts *http.PushOptions) error {
+	return ew.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// stream sends the headers and the buffered body, and the rest of the response
+// from then on, without ETag
+func (ew *etagWriter) stream() error {
+	ew.streaming = true
+
+	if ew.code == 0 {
+		ew.code = http.StatusOK
+	}
+	ew.ResponseWriter.WriteHeader(ew.code)
+
+	if ew.buf.Len() > 0 {
+		_, err := ew.ResponseWriter.Write(ew.buf.Bytes())
+		ew.buf.Reset()
+		return err
+	}
+
+	return nil
+}
+
+// close tags the buffered response and sends it, or the outcome of the
+// evaluation of the request preconditions
+func (ew *etagWriter) close() {
+	if ew.streaming || (ew.code == 0 && ew.disabled()) {
+		return
+	}
+
+	if ew.code == 0 {
+		ew.code = http.StatusOK
+	}
+
+	if ew.code != http.StatusOK {
+		_ = ew.stream()
+		return
+	}
+
+	// HEAD handlers may not write the body, whose tag and length are unknown
+	bodiless := ew.request.Method == http.MethodHead && ew.buf.Len() == 0
+
+	header := ew.Header()
+	etag := header.Get("ETag")
+	if etag == "" {
+		if bodiless {
+			_ = ew.stream()
+			return
+		}
+
+		etag = ew.generate()
+		header.Set("ETag", etag)
+	}
+
+	switch code := evaluatePreconditions(ew.request, etag, header.Get("Last-Modified")); code {
+	case http.StatusNotModified:
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		header.Del("Content-Encoding")
+		header.Del("Last-Modified")
+		ew.ResponseWriter.WriteHeader(code)
+	case http.StatusPreconditionFailed:
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		header.Del("Content-Encoding")
+		ew.ResponseWriter.WriteHeader(code)
+	default:
+		if !bodiless && header.Get("Content-Length") == "" {
+			header.Set("Content-Length", strconv.Itoa(ew.buf.Len()))
+		}
+		_ = ew.stream()
+	}
+}
+
+func (ew *etagWriter) generate() string {
+	sum := sha256.Sum256(ew.buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if ew.weak {
+		return "W/" + etag
+	}
+	return etag
+}
+
+func (ew *etagWriter) wrapped() http.ResponseWriter {
+	return ew.ResponseWriter
+}
+
+func (ew *etagWriter) wrapper() wrappingWriter {
+	return ew
+}
+
+// evaluatePreconditions evaluates the conditional headers of a GET or HEAD
+// request as defined in RFC 7232, returning the status code to respond with,
+// or 0 if the full response must be sent
+func evaluatePreconditions(r *http.Request, etag, lastModified string) int {
+	if ifMatch := strings.Join(r.Header["If-Match"], ","); ifMatch != "" {
+		if !etagListMatches(ifMatch, etag, true) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	if ifNoneMatch := strings.Join(r.Header["If-None-Match"], ","); ifNoneMatch != "" {
+		if etagListMatches(ifNoneMatch, etag, false) {
+			return http.StatusNotModified
+		}
+		return 0
+	}
+
+	ifModifiedSince := r.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || lastModified == "" {
+		return 0
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return 0
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return 0
+	}
+
+	if !modified.After(since) {
+		return http.StatusNotModified
+	}
+	return 0
+}
+
+// etagListMatches checks if an ETag is in a list of them, like the one of the
+// If-Match or If-None-Match headers, using the strong or weak comparison
+func etagListMatches(list, etag string, strong bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+
+	for {
+		list = strings.TrimLeft(list, " \t,")
+		if list == "" {
+			return false
+		}
+
+		var tag string
+		tag, list = scanETag(list)
+		if tag == "" {
+			return false
+		}
+
+		if etagsMatch(tag, etag, strong) {
+			return true
+		}
+	}
+}
+
+// scanETag returns the ETag at the beginning of s and the rest of it, or an
+// empty ETag if s does not start with a valid one
+func scanETag(s string) (string, string) {
+	start := 0
+	if strings.HasPrefix(s, "W/") {
+		start = 2
+	}
+
+	if len(s) < start+2 || s[start] != '"' {
+		return "", ""
+	}
+
+	end := strings.IndexByte(s[start+1:], '"')
+	if end < 0 {
+		return "", ""
+	}
+	end += start + 2
+
+	return s[:end], s[end:]
+}
+
+func etagsMatch(a, b string, strong bool) bool {
+	if strong {
+		return a == b && !strings.HasPrefix(a, "W/")
+	}
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
@@ -0,0 +1,176 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestETag_TagsResponsesAndAnswersIfNoneMatch(t *testing.T) {
+	mainRouter := Router{}
+	_ = mainRouter.Get("/catalog", testDummyHandlerFunc)
+	handler := NewMiddlewarePipe().Next(ETag()).Then(mainRouter.ServeHTTP)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/catalog", nil)
+	handler(rec, req)
+
+	etag := rec.Header().Get("ETag")
+	assertEqual(t, http.StatusOK, rec.Code)
+	assertStringEqual(t, "dummy", rec.Body.String())
+	assertStringEqual(t, "5", rec.Header().Get("Content-Length"))
+	assertTrue(t, strings.HasPrefix(etag, `"`) && strings.HasSuffix(etag, `"`))
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/catalog", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	handler(rec, req)
+
+	assertEqual(t, http.StatusNotModified, rec.Code)
+	assertStringEqual(t, "", rec.Body.String())
+	assertStringEqual(t, etag, rec.Header().Get("ETag"))
+	assertStringEqual(t, "", rec.Header().Get("Content-Type"))
+}
+
+func TestETag_AnswersIfMatchWithStrongComparison(t *testing.T) {
+	handler := NewMiddlewarePipe().Next(ETag(ETagOptions{Weak: true})).Then(testDummyHandlerFunc)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler(rec, req)
+
+	etag := rec.Header().Get("ETag")
+	assertTrue(t, strings.HasPrefix(etag, `W/"`))
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	req.Header.Set("If-Match", etag)
+	handler(rec, req)
+
+	assertEqual(t, http.StatusPreconditionFailed, rec.Code)
+	assertStringEqual(t, "", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	req.Header.Set("If-Match", "*")
+	handler(rec, req)
+
+	assertEqual(t, http.StatusOK, rec.Code)
+	assertStringEqual(t, "dummy", rec.Body.String())
+}
+
+func TestETag_AnswersIfModifiedSince(t *testing.T) {
+	handler := NewMiddlewarePipe().Next(ETag()).Then(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 05 Oct 2026 10:00:00 GMT")
+		_, _ = w.Write([]byte("catalog"))
+	})
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("If-Modified-Since", "Mon, 05 Oct 2026 10:00:00 GMT")
+	handler(rec, req)
+
+	assertEqual(t, http.StatusNotModified, rec.Code)
+	assertStringEqual(t, `"v1"`, rec.Header().Get("ETag"))
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	req.Header.Set("If-Modified-Since", "Sun, 04 Oct 2026 10:00:00 GMT")
+	handler(rec, req)
+
+	assertEqual(t, http.StatusOK, rec.Code)
+	assertStringEqual(t, "catalog", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", `"v0"`)
+	req.Header.Set("If-Modified-Since", "Mon, 05 Oct 2026 10:00:00 GMT")
+	handler(rec, req)
+
+	assertEqual(t, http.StatusOK, rec.Code)
+}
+
+func TestETag_SendsUntaggedResponses(t *testing.T) {
+	mainRouter := Router{}
+	_ = mainRouter.Get("/large", testHandlerFunc)
+	_ = mainRouter.Get("/stream", testDummyHandlerFunc, MatchingOptions{DisableETag: true})
+	_ = mainRouter.Get("/error", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "failed", http.StatusInternalServerError)
+	})
+	_ = mainRouter.Post("/catalog", testDummyHandlerFunc)
+	handler := NewMiddlewarePipe().Next(ETag(ETagOptions{MaxSize: 4})).Then(mainRouter.ServeHTTP)
+
+	for _, test := range []struct {
+		method, path string
+		code         int
+	}{
+		{"GET", "/large", http.StatusOK},
+		{"GET", "/stream", http.StatusOK},
+		{"GET", "/error", http.StatusInternalServerError},
+		{"POST", "/catalog", http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(test.method, test.path, nil)
+		req.Header.Set("If-None-Match", "*")
+		handler(rec, req)
+
+		assertEqual(t, test.code, rec.Code)
+		assertStringEqual(t, "", rec.Header().Get("ETag"))
+	}
+}
+
+func TestETag_StreamsFlushedResponses(t *testing.T) {
+	handler := NewMiddlewarePipe().Next(ETag()).Then(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("chunk"))
+		w.(http.Flusher).Flush()
+	})
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler(rec, req)
+
+	assertTrue(t, rec.Flushed)
+	assertStringEqual(t, "chunk", rec.Body.String())
+	assertStringEqual(t, "", rec.Header().Get("ETag"))
+}
+
+func TestEtagListMatches(t *testing.T) {
+	assertTrue(t, etagListMatches(`"a", "b,c"`, `"b,c"`, true))
+	assertTrue(t, etagListMatches(`W/"a"`, `"a"`, false))
+	assertFalse(t, etagListMatches(`W/"a"`, `"a"`, true))
+	assertFalse(t, etagListMatches(`"a`, `"a"`, false))
+	assertFalse(t, etagListMatches(`a`, `"a"`, false))
+}
+
+func TestETag_ExposesOnlyInterfacesOfWrappedWriter(t *testing.T) {
+	handler := ETag()(func(w http.ResponseWriter, r *http.Request) {
+		_, isFlusher := w.(http.Flusher)
+		_, isHijacker := w.(http.Hijacker)
+		assertTrue(t, isFlusher)
+		assertFalse(t, isHijacker)
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler(httptest.NewRecorder(), req)
+}
+
+func TestETag_LeavesResponsesWithoutBodyUntagged(t *testing.T) {
+	mainRouter := Router{}
+	_ = mainRouter.Head("/catalog", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+	})
+	_ = mainRouter.Get("/events", func(w http.ResponseWriter, r *http.Request) {}, MatchingOptions{DisableETag: true})
+	handler := NewMiddlewarePipe().Next(ETag()).Then(mainRouter.ServeHTTP)
+
+	for _, c := range []struct{ method, path string }{{"HEAD", "/catalog"}, {"GET", "/events"}} {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(c.method, c.path, nil)
+		handler(rec, req)
+
+		assertEqual(t, http.StatusOK, rec.Code)
+		assertStringEqual(t, "", rec.Header().Get("ETag"))
+		assertStringEqual(t, "", rec.Header().Get("Content-Length"))
+	}
+}
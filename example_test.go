@@ -8,7 +8,9 @@ import (
 )
 
 func init() {
-	routing.AddHandler(MyHandler, "myhandler")
+	if err := routing.AddHandler(MyHandler, "myhandler"); err != nil {
+		panic(err)
+	}
 }
 
 func MyHandler(w http.ResponseWriter, r *http.Request) {
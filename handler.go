@@ -10,21 +10,25 @@ func getAutoMethodOptionsHandler(router *Router) http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
 		availVerbs := getAvailableMethods(router, request)
 
-		writer.WriteHeader(http.StatusNoContent)
 		writer.Header().Set("Allow", strings.Join(availVerbs, ", "))
+		writer.WriteHeader(http.StatusNoContent)
 	}
 }
 
 func getRedirectHandler(url string, code ...int) http.HandlerFunc {
-	defaultCode := http.StatusFound
-	if len(code) > 0 && code[0] >= http.StatusMultipleChoices && code[0] <= http.StatusPermanentRedirect {
-		defaultCode = code[0]
-	}
+	defaultCode := redirectCode(code...)
 	return func(writer http.ResponseWriter, request *http.Request) {
 		http.Redirect(writer, request, url, defaultCode)
 	}
 }
 
+func redirectCode(code ...int) int {
+	if len(code) > 0 && code[0] >= http.StatusMultipleChoices && code[0] <= http.StatusPermanentRedirect {
+		return code[0]
+	}
+	return http.StatusFound
+}
+
 func getAvailableMethods(router *Router, request *http.Request) []string {
 	availVerbs := make([]string, 0, 9)
 	for verb, tree := range router.trees {
@@ -0,0 +1,66 @@
+package routing
+
+import (
+	"net/http"
+	"time"
+)
+
+// RouterHooks are callbacks called by a Router while serving the requests, to
+// observe its internals, for instance to trace or measure them. Nil callbacks
+// are ignored. Callbacks run in the serving goroutine so they should be fast.
+type RouterHooks struct {
+	// OnLookup is called after looking up the route of a request, matched or
+	// not, with the time it took
+	OnLookup func(r *http.Request, duration time.Duration)
+	// OnMatch is called before calling the handler of the route matching a
+	// request, whose name and template are available through RouteName and
+	// RouteTemplate
+	OnMatch func(r *http.Request)
+	// OnNotFound is called before responding a request matching no route
+	OnNotFound func(r *http.Request)
+	// OnMethodNotAllowed is called before responding a request matching the
+	// routes of other methods only, with the allowed methods
+	OnMethodNotAllowed func(r *http.Request, allowed []string)
+	// OnRedirect is called before redirecting a request, either by a route
+	// registered with Redirect or to enforce https, with the location and the
+	// status code of the redirection
+	OnRedirect func(r *http.Request, location string, code int)
+}
+
+// find looks up the route of the request in the tree, timing it if there is
+// an OnLookup hook
+func (h *RouterHooks) find(t *tree, r *http.Request) *node {
+	if h.OnLookup == nil {
+		return t.find(r)
+	}
+
+	start := time.Now()
+	leaf := t.find(r)
+	h.OnLookup(r, time.Since(start))
+
+	return leaf
+}
+
+func (h *RouterHooks) match(r *http.Request) {
+	if h.OnMatch != nil {
+		h.OnMatch(r)
+	}
+}
+
+func (h *RouterHooks) notFound(r *http.Request) {
+	if h.OnNotFound != nil {
+		h.OnNotFound(r)
+	}
+}
+
+func (h *RouterHooks) methodNotAllowed(r *http.Request, allowed []string) {
+	if h.OnMethodNotAllowed != nil {
+		h.OnMethodNotAllowed(r, allowed)
+	}
+}
+
+func (h *RouterHooks) redirect(r *http.Request, location string, code int) {
+	if h.OnRedirect != nil {
+		h.OnRedirect(r, location, code)
+	}
+}
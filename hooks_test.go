@@ -0,0 +1,82 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouterHooks_AreCalledOnMatchAndLookup(t *testing.T) {
+	var lookups int
+	var matched string
+
+	mainRouter := NewRouter(RouterConfig{Hooks: RouterHooks{
+		OnLookup: func(r *http.Request, duration time.Duration) {
+			lookups++
+		},
+		OnMatch: func(r *http.Request) {
+			matched = RouteName(r) + " " + RouteTemplate(r)
+		},
+	}})
+	_ = mainRouter.Get("/users/{id}", testHandlerFunc, MatchingOptions{Name: "users.show"})
+
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	mainRouter.ServeHTTP(httptest.NewRecorder(), req)
+
+	assertEqual(t, 1, lookups)
+	assertStringEqual(t, "users.show /users/{id}", matched)
+}
+
+func TestRouterHooks_AreCalledOnNotFoundAndMethodNotAllowed(t *testing.T) {
+	var notFound, allowed string
+
+	mainRouter := NewRouter(RouterConfig{
+		EnableMethodNotAllowedResponse: true,
+		Hooks: RouterHooks{
+			OnNotFound: func(r *http.Request) {
+				notFound = r.URL.Path
+			},
+			OnMethodNotAllowed: func(r *http.Request, methods []string) {
+				allowed = strings.Join(methods, ", ")
+			},
+		},
+	})
+	_ = mainRouter.Get("/users", testHandlerFunc)
+	_ = mainRouter.Post("/posts", testHandlerFunc)
+
+	req, _ := http.NewRequest("POST", "/users", nil)
+	mainRouter.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", "/posts/1", nil)
+	mainRouter.ServeHTTP(httptest.NewRecorder(), req)
+
+	assertStringEqual(t, "GET", allowed)
+	assertStringEqual(t, "/posts/1", notFound)
+}
+
+func TestRouterHooks_AreCalledOnRedirect(t *testing.T) {
+	var redirects []string
+
+	mainRouter := NewRouter(RouterConfig{
+		ForceHTTPS: true,
+		Hooks: RouterHooks{
+			OnRedirect: func(r *http.Request, location string, code int) {
+				redirects = append(redirects, location+" "+http.StatusText(code))
+			},
+		},
+	})
+	_ = mainRouter.Redirect("/old", "/new", http.StatusMovedPermanently)
+	_ = mainRouter.Get("/secure", testHandlerFunc, MatchingOptions{Schemas: []string{"https"}})
+
+	req, _ := http.NewRequest("GET", "http://example.com/old", nil)
+	mainRouter.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", "http://example.com/secure", nil)
+	mainRouter.ServeHTTP(httptest.NewRecorder(), req)
+
+	assertEqual(t, 2, len(redirects))
+	assertStringEqual(t, "/new Moved Permanently", redirects[0])
+	assertStringEqual(t, "https://example.com/secure Moved Permanently", redirects[1])
+}
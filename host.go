@@ -0,0 +1,305 @@
+package routing
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const wildcardPrefix = "*."
+
+// hostMatcher matches the request host against a host pattern like
+// {tenant}.example.com, *.example.com or example.com:8080. Hosts are compared
+// case insensitively, with internationalized names in their punycode form. The
+// request port is ignored unless the pattern has one.
+type hostMatcher struct {
+	root     *node
+	leaf     *node
+	port     int
+	wildcard bool
+}
+
+func byHost(host string) (*hostMatcher, error) {
+	hostname, port := splitHostPattern(host)
+
+	m := &hostMatcher{}
+	if port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil || p <= 0 || p > 65535 {
+			return nil, fmt.Errorf("host %s has an invalid port %s", host, port)
+		}
+		m.port = p
+	}
+
+	if strings.HasPrefix(hostname, wildcardPrefix) {
+		m.wildcard = true
+		hostname = hostname[len(wildcardPrefix):]
+	}
+
+	parser := newParser("/" + normalizeHostPattern(hostname))
+	_, err := parser.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	m.root, m.leaf = createTreeFromChunks(parser.chunks)
+	m.leaf.handler = func(writer http.ResponseWriter, request *http.Request) {}
+
+	return m, nil
+}
+
+func (m *hostMatcher) match(r *http.Request) (bool, *node) {
+	if r == nil {
+		return false, nil
+	}
+
+	if m.port != 0 && m.port != requestPort(r) {
+		return false, nil
+	}
+
+	hostname, ok := m.hostname(r)
+	if !ok {
+		return false, nil
+	}
+
+	return nil != find(m.root, "/"+hostname, r), nil
+}
+
+func (m *hostMatcher) hasParameters() bool {
+	return m.leaf.hasParameters()
+}
+
+// parameters returns the values captured by the variables of the host pattern
+func (m *hostMatcher) parameters(r *http.Request) URLParameterBag {
+	hostname, ok := m.hostname(r)
+	if !ok {
+		return newURLParameterBag(0)
+	}
+
+	return buildURLParameters(m.leaf, hostname, len(hostname), 0)
+}
+
+// hostname returns the normalized request host to match against the pattern,
+// without the label matched by the wildcard if any
+func (m *hostMatcher) hostname(r *http.Request) (string, bool) {
+	hostname := normalizeHost(r.Host)
+	if !m.wildcard {
+		return hostname, true
+	}
+
+	dot := strings.IndexByte(hostname, '.')
+	if dot <= 0 {
+		return "", false
+	}
+
+	return hostname[dot+1:], true
+}
+
+// splitHostPattern splits a host pattern into hostname and port, ignoring the
+// colons of the variable regular expressions
+func splitHostPattern(pattern string) (string, string) {
+	braces := 0
+	for i := len(pattern) - 1; i >= 0; i-- {
+		switch pattern[i] {
+		case '}':
+			braces++
+		case '{':
+			braces--
+		case ':':
+			if braces == 0 {
+				return pattern[:i], pattern[i+1:]
+			}
+		}
+	}
+
+	return pattern, ""
+}
+
+// normalizeHost returns the request host without port nor trailing dot, lower
+// cased and with internationalized labels in punycode. Full width characters
+// and ideographic full stops are mapped like UTS #46 does, but labels are not
+// normalized to NFC, which requires the Unicode tables of golang.org/x/text.
+// Clients usually send hosts already encoded in punycode anyway.
+func normalizeHost(host string) string {
+	host = strings.Map(mapHostRune, host)
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		labels[i] = labelToASCII(label)
+	}
+
+	return strings.Join(labels, ".")
+}
+
+// mapHostRune maps the full width forms of ASCII characters to them and the
+// ideographic full stops to dots
+func mapHostRune(r rune) rune {
+	switch {
+	case r >= '\uFF01' && r <= '\uFF5E':
+		return r - 0xFEE0
+	case r == '\u3002' || r == '\uFF61':
+		return '.'
+	}
+	return r
+}
+
+// normalizeHostPattern normalizes a host pattern like normalizeHost does, but
+// only out of its variables, whose regular expressions are kept as they are,
+// and converting to punycode only the labels without variables
+func normalizeHostPattern(pattern string) string {
+	var labels []string
+	var label strings.Builder
+	variable, braces := false, 0
+	for _, ch := range pattern {
+		if braces == 0 {
+			ch = unicode.ToLower(mapHostRune(ch))
+		}
+
+		switch {
+		case ch == '{':
+			braces++
+			variable = true
+		case ch == '}':
+			braces--
+		case ch == '.' && braces == 0:
+			labels = append(labels, patternLabel(label.String(), variable))
+			label.Reset()
+			variable = false
+			continue
+		}
+		label.WriteRune(ch)
+	}
+	labels = append(labels, patternLabel(label.String(), variable))
+
+	return strings.TrimSuffix(strings.Join(labels, "."), ".")
+}
+
+func patternLabel(label string, variable bool) string {
+	if variable {
+		return label
+	}
+
+	return labelToASCII(label)
+}
+
+// labelToASCII converts a domain name label with non ASCII characters to its
+// ASCII compatible encoding as defined in RFC 3490
+func labelToASCII(label string) string {
+	for i := 0; i < len(label); i++ {
+		if label[i] >= utf8.RuneSelf {
+			return "xn--" + punycode(label)
+		}
+	}
+
+	return label
+}
+
+const (
+	punycodeBase        = 36
+	punycodeTMin        = 1
+	punycodeTMax        = 26
+	punycodeSkew        = 38
+	punycodeDamp        = 700
+	punycodeInitialBias = 72
+	punycodeInitialN    = 128
+)
+
+// punycode encodes a string with the Punycode algorithm defined in RFC 3492
+func punycode(s string) string {
+	runes := []rune(s)
+
+	var out strings.Builder
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out.WriteRune(r)
+		}
+	}
+
+	basic := out.Len()
+	if basic > 0 {
+		out.WriteByte('-')
+	}
+
+	n, delta, bias := punycodeInitialN, 0, punycodeInitialBias
+	for h := basic; h < len(runes); {
+		m := int(utf8.MaxRune) + 1
+		for _, r := range runes {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+
+		delta += (m - n) * (h + 1)
+		n = m
+
+		for _, r := range runes {
+			if int(r) < n {
+				delta++
+			}
+			if int(r) != n {
+				continue
+			}
+
+			q := delta
+			for k := punycodeBase; ; k += punycodeBase {
+				t := k - bias
+				if t < punycodeTMin {
+					t = punycodeTMin
+				} else if t > punycodeTMax {
+					t = punycodeTMax
+				}
+
+				if q < t {
+					break
+				}
+
+				out.WriteByte(punycodeDigit(t + (q-t)%(punycodeBase-t)))
+				q = (q - t) / (punycodeBase - t)
+			}
+			out.WriteByte(punycodeDigit(q))
+
+			bias = punycodeAdapt(delta, h+1, h == basic)
+			delta = 0
+			h++
+		}
+
+		delta++
+		n++
+	}
+
+	return out.String()
+}
+
+func punycodeAdapt(delta, points int, first bool) int {
+	if first {
+		delta /= punycodeDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / points
+
+	k := 0
+	for delta > ((punycodeBase-punycodeTMin)*punycodeTMax)/2 {
+		delta /= punycodeBase - punycodeTMin
+		k += punycodeBase
+	}
+
+	return k + (punycodeBase-punycodeTMin+1)*delta/(delta+punycodeSkew)
+}
+
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
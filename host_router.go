@@ -0,0 +1,124 @@
+package routing
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type hostParamsKey int
+
+var hostCtxKey hostParamsKey
+
+// HostRouter dispatches requests by host to separate handlers, usually
+// routers, before any path lookup. Hosts with a port are looked up before the
+// ones without it. Among them, static hosts are resolved with a map lookup
+// before host patterns, which are matched in the order they were registered.
+// The values captured by the host patterns are available through
+// GetURLParameters.
+type HostRouter struct {
+	static   map[string]http.Handler
+	patterns []hostRoute
+	fallback http.Handler
+}
+
+type hostRoute struct {
+	matcher *hostMatcher
+	handler http.Handler
+}
+
+// NewHostRouter returns a HostRouter dispatching to the fallback handler the
+// requests whose host is not registered, a nil fallback responds not found
+func NewHostRouter(fallback http.Handler) HostRouter {
+	return HostRouter{fallback: fallback}
+}
+
+// Host registers the handler of the requests whose host matches the pattern,
+// which accepts the syntax of MatchingOptions.Host
+func (h *HostRouter) Host(pattern string, handler http.Handler) error {
+	m, err := byHost(pattern)
+	if err != nil {
+		return err
+	}
+
+	if !m.wildcard && !strings.ContainsAny(pattern, "{}") {
+		if h.static == nil {
+			h.static = make(map[string]http.Handler)
+		}
+		hostname, _ := splitHostPattern(pattern)
+		h.static[staticHostKey(normalizeHostPattern(hostname), m.port)] = handler
+		return nil
+	}
+
+	h.patterns = append(h.patterns, hostRoute{matcher: m, handler: handler})
+	return nil
+}
+
+// Fallback sets the handler of the requests whose host is not registered
+func (h *HostRouter) Fallback(handler http.Handler) {
+	h.fallback = handler
+}
+
+func (h *HostRouter) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	host := normalizeHost(request.Host)
+
+	if handler, ok := h.static[staticHostKey(host, requestPort(request))]; ok {
+		handler.ServeHTTP(response, request)
+		return
+	}
+
+	if h.servePatterns(response, request, true) {
+		return
+	}
+
+	if handler, ok := h.static[host]; ok {
+		handler.ServeHTTP(response, request)
+		return
+	}
+
+	if h.servePatterns(response, request, false) {
+		return
+	}
+
+	if h.fallback != nil {
+		h.fallback.ServeHTTP(response, request)
+		return
+	}
+
+	http.NotFound(response, request)
+}
+
+// servePatterns serves the request with the handler of the first host pattern
+// with or without port matching it, if any
+func (h *HostRouter) servePatterns(response http.ResponseWriter, request *http.Request, withPort bool) bool {
+	for _, route := range h.patterns {
+		if (route.matcher.port != 0) != withPort {
+			continue
+		}
+
+		if matches, _ := route.matcher.match(request); !matches {
+			continue
+		}
+
+		if route.matcher.hasParameters() {
+			params := route.matcher.parameters(request)
+			request = request.WithContext(context.WithValue(request.Context(), hostCtxKey, params))
+		}
+		route.handler.ServeHTTP(response, request)
+		return true
+	}
+
+	return false
+}
+
+// staticHostKey returns the key of a static host in the map of a HostRouter,
+// with the port if any
+func staticHostKey(host string, port int) string {
+	if port == 0 {
+		return host
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
@@ -0,0 +1,131 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHostRouter_DispatchesByHost(t *testing.T) {
+	apiRouter := Router{}
+	_ = apiRouter.Get("/users", testHandlerFunc)
+
+	bag := newURLParameterBag(2)
+	bag.add("id", "1")
+	bag.add("tenant", "acme")
+	tenantRouter := Router{}
+	_ = tenantRouter.Get("/users/{id}", assertRequestHasParameterHandler(t, bag))
+	_ = tenantRouter.Get("/about", func(w http.ResponseWriter, r *http.Request) {
+		params := GetURLParameters(r)
+		tenant, _ := params.GetByName("tenant")
+		_, _ = w.Write([]byte(tenant))
+	})
+
+	fallbackRouter := Router{}
+	_ = fallbackRouter.Get("/users", testDummyHandlerFunc)
+
+	hostRouter := NewHostRouter(&fallbackRouter)
+	assertNil(t, hostRouter.Host("api.example.com", &apiRouter))
+	assertNil(t, hostRouter.Host("{tenant}.example.com", &tenantRouter))
+
+	cases := []struct {
+		host, path string
+		code       int
+		body       string
+	}{
+		{"API.example.com:8080", "/users", http.StatusOK, "/users"},
+		{"acme.example.com", "/users/1", http.StatusOK, "/users/1"},
+		{"acme.example.com", "/about", http.StatusOK, "acme"},
+		{"acme.example.com", "/users", http.StatusNotFound, ""},
+		{"other.com", "/users", http.StatusOK, "dummy"},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", c.path, nil)
+		req.Host = c.host
+		res := httptest.NewRecorder()
+		hostRouter.ServeHTTP(res, req)
+
+		assertEqual(t, c.code, res.Code)
+		if c.body != "" {
+			assertStringEqual(t, c.body, res.Body.String())
+		}
+	}
+}
+
+func TestHostRouter_RouteParametersWinOverHostRouterOnes(t *testing.T) {
+	tenantRouter := Router{}
+	_ = tenantRouter.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		params := GetURLParameters(r)
+		for _, p := range params.params {
+			_, _ = w.Write([]byte(p.name + "=" + p.value + " "))
+		}
+	}, MatchingOptions{Host: "{tenant:[a-z]+}.{zone}.example.com"})
+
+	hostRouter := NewHostRouter(nil)
+	assertNil(t, hostRouter.Host("{tenant}.{region}.example.com", &tenantRouter))
+
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	req.Host = "acme.eu.example.com"
+	res := httptest.NewRecorder()
+	hostRouter.ServeHTTP(res, req)
+
+	assertEqual(t, http.StatusOK, res.Code)
+	assertStringEqual(t, "id=1 region=eu tenant=acme zone=eu ", res.Body.String())
+}
+
+func TestHostRouter_RespondsNotFoundWithoutFallback(t *testing.T) {
+	hostRouter := HostRouter{}
+	_ = hostRouter.Host("*.example.com", http.HandlerFunc(testHandlerFunc))
+
+	req, _ := http.NewRequest("GET", "/users", nil)
+	req.Host = "example.com"
+	res := httptest.NewRecorder()
+	hostRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusNotFound, res.Code)
+
+	hostRouter.Fallback(http.HandlerFunc(testDummyHandlerFunc))
+	res = httptest.NewRecorder()
+	hostRouter.ServeHTTP(res, req)
+	assertStringEqual(t, "dummy", res.Body.String())
+}
+
+func TestHostRouter_Host_ReturnsErrorWhenMalformedHost(t *testing.T) {
+	hostRouter := HostRouter{}
+
+	err := hostRouter.Host("app.{subdomain:[a-z]+}{m}.test2.com", http.HandlerFunc(testHandlerFunc))
+	assertNotNil(t, err)
+}
+
+func TestHostRouter_PrefersHostsWithPort(t *testing.T) {
+	writer := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(body))
+		})
+	}
+
+	hostRouter := NewHostRouter(nil)
+	assertNil(t, hostRouter.Host("example.com", writer("any port")))
+	assertNil(t, hostRouter.Host("example.com:8080", writer("8080")))
+	assertNil(t, hostRouter.Host("{sub}.example.com", writer("any port pattern")))
+	assertNil(t, hostRouter.Host("{sub}.example.com:9090", writer("9090 pattern")))
+
+	cases := []struct {
+		host, body string
+	}{
+		{"example.com", "any port"},
+		{"example.com:8080", "8080"},
+		{"example.com:9090", "any port"},
+		{"api.example.com:8080", "any port pattern"},
+		{"api.example.com:9090", "9090 pattern"},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Host = c.host
+		res := httptest.NewRecorder()
+		hostRouter.ServeHTTP(res, req)
+
+		assertStringEqual(t, c.body, res.Body.String())
+	}
+}
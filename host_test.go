@@ -0,0 +1,79 @@
+package routing
+
+import (
+	"net/http"
+	"testing"
+)
+
+func Test_byHost_MatchesPortsWildcardsAndIDN(t *testing.T) {
+	cases := []struct {
+		pattern, host string
+		matches       bool
+	}{
+		{"example.com", "example.com:8080", true},
+		{"example.com", "EXAMPLE.com.", true},
+		{"example.com:8080", "example.com:8080", true},
+		{"example.com:8080", "example.com:9090", false},
+		{"example.com:8080", "example.com", false},
+		{"example.com:80", "example.com", true},
+		{"{sub:[a-z]+}.example.com:8080", "api.example.com:8080", true},
+		{"*.example.com", "api.example.com", true},
+		{"*.example.com", "API.Example.com:443", true},
+		{"*.example.com", "example.com", false},
+		{"*.example.com", "v1.api.example.com", false},
+		{"*.{tenant}.example.com", "v1.acme.example.com", true},
+		{"bücher.example", "xn--bcher-kva.example", true},
+		{"bücher.example", "BÜCHER.example", true},
+		{"xn--mnchen-3ya.de", "münchen.de", true},
+		{"bücher.example", "BüCHER.Example", true},
+		{"example.com", "ｅｘａｍｐｌｅ．ｃｏｍ", true},
+		{"example.com:8080", "ＥＸＡＭＰＬＥ。com:8080", true},
+		{"日本語.jp", "日本語｡ＪＰ", true},
+		{"bücher.example", "bu\u0308cher.example", false},
+		{"{t}.bücher.example", "a.xn--bcher-kva.example", true},
+		{"{t}.BÜCHER.example", "a.bücher.example", true},
+		{"{t:\\D+}.example.com", "abc.example.com", true},
+		{"{t:\\D+}.example.com", "a1.example.com", false},
+		{"{t:[a-z.]+}.example.com", "a.b.example.com", true},
+	}
+
+	for _, c := range cases {
+		m, err := byHost(c.pattern)
+		assertNil(t, err)
+
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Host = c.host
+		matches, _ := m.match(req)
+		if matches != c.matches {
+			t.Errorf("host %s matching %s: expected %v", c.host, c.pattern, c.matches)
+		}
+	}
+}
+
+func Test_byHost_ReturnsErrorWhenInvalidPort(t *testing.T) {
+	_, err := byHost("example.com:http")
+	assertNotNil(t, err)
+
+	_, err = byHost("example.com:70000")
+	assertNotNil(t, err)
+}
+
+func Test_hostMatcher_Parameters(t *testing.T) {
+	m, _ := byHost("*.{tenant}.example.com:8080")
+	assertTrue(t, m.hasParameters())
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Host = "v1.acme.example.com:8080"
+
+	bag := m.parameters(req)
+	tenant, _ := bag.GetByName("tenant")
+	assertStringEqual(t, "acme", tenant)
+}
+
+func Test_punycode(t *testing.T) {
+	assertStringEqual(t, "bcher-kva", punycode("bücher"))
+	assertStringEqual(t, "mnchen-3ya", punycode("münchen"))
+	assertStringEqual(t, "wgv71a119e", punycode("日本語"))
+	assertStringEqual(t, "xn--wgv71a119e", labelToASCII("日本語"))
+	assertStringEqual(t, "example", labelToASCII("example"))
+}
@@ -24,8 +24,10 @@ const (
 )
 
 type lexer struct {
-	mode int
-	buf  *bufio.Reader
+	mode     int
+	buf      *bufio.Reader
+	values   bool
+	variable bool
 }
 
 func newLexer(path string) *lexer {
@@ -33,6 +35,16 @@ func newLexer(path string) *lexer {
 	return &lexer{buf: bufio.NewReader(reader), mode: tModeStatic}
 }
 
+// newValueLexer returns a lexer for header or query parameter values, where
+// slashes and any other character but braces are static, and colons start a
+// regular expression only inside variables
+func newValueLexer(value string) *lexer {
+	l := newLexer(value)
+	l.values = true
+
+	return l
+}
+
 func (l *lexer) scan() token {
 	ch, _, err := l.buf.ReadRune()
 
@@ -49,26 +61,28 @@ func (l *lexer) scan() token {
 		}
 	}
 
-	if isColon(ch) {
+	if isColon(ch) && (!l.values || l.variable) {
 		return l.scanExpRegular()
 	}
 
 	if isCloseBrace(ch) {
+		l.variable = false
 		return createCloseVarToken()
 	}
 
 	// defaults to Static mode
 
-	if isSlash(ch) {
+	if isSlash(ch) && !l.values {
 		return createSlashToken()
 	}
 
 	if isOpenBrace(ch) {
 		l.mode = tModeIdentifier
+		l.variable = true
 		return createOpenVarToken()
 	}
 
-	if !isStatic(ch) {
+	if !l.isStatic(ch) {
 		panic(fmt.Sprintf("character not allowed detected: %c", ch))
 	}
 
@@ -86,7 +100,7 @@ func (l *lexer) scanStatic() token {
 			break
 		}
 
-		if !isStatic(ch) {
+		if !l.isStatic(ch) || (l.variable && isColon(ch)) {
 			_ = l.buf.UnreadRune()
 			break
 		}
@@ -97,6 +111,14 @@ func (l *lexer) scanStatic() token {
 	return createStaticToken(out.String())
 }
 
+func (l *lexer) isStatic(ch rune) bool {
+	if l.values {
+		return !isOpenBrace(ch) && !isCloseBrace(ch)
+	}
+
+	return isStatic(ch)
+}
+
 func (l *lexer) scanIdentifier() token {
 	var out bytes.Buffer
 
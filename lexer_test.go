@@ -251,3 +251,34 @@ func TestLexer_ScanAll_WithExpRegular(t *testing.T) {
 	}
 	validateTokens(expectedTokens, tokens, t)
 }
+
+func TestLexer_ScanAll_ValueWithVariables(t *testing.T) {
+	lexer := newValueLexer("text/v{version:[0-9]+}+json; q=0.5")
+
+	tokens := lexer.scanAll()
+	expectedTokens := []token{
+		{v: "text/v", t: tStatic},
+		{v: "{", t: tOpenVar},
+		{v: "version", t: tVar},
+		{v: "[0-9]+", t: tExpReg},
+		{v: "}", t: tCloseVar},
+		{v: "+json; q=0.5", t: tStatic},
+		{v: "", t: tEnd},
+	}
+	validateTokens(expectedTokens, tokens, t)
+}
+
+func TestLexer_ScanAll_ValueWithColonsOutsideVariables(t *testing.T) {
+	lexer := newValueLexer("urn:{id}:v1")
+
+	tokens := lexer.scanAll()
+	expectedTokens := []token{
+		{v: "urn:", t: tStatic},
+		{v: "{", t: tOpenVar},
+		{v: "id", t: tVar},
+		{v: "}", t: tCloseVar},
+		{v: ":v1", t: tStatic},
+		{v: "", t: tEnd},
+	}
+	validateTokens(expectedTokens, tokens, t)
+}
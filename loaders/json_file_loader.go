@@ -2,26 +2,50 @@ package loaders
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/golossus/routing"
 	"io/ioutil"
+	"path/filepath"
+	"strings"
 )
 
-// JsonRoutes defines a json collection of routes
+// JsonRoutes defines a json collection of routes. Prefix and Defaults apply to
+// all the routes of the collection and to the ones of the included files,
+// whose paths are relative to the file including them.
 type JsonRoutes struct {
-	Routes []JsonRoute `json:"routes"`
+	Include  []string     `json:"include"`
+	Prefix   string       `json:"prefix"`
+	Defaults JsonDefaults `json:"defaults"`
+	Routes   []JsonRoute  `json:"routes"`
+}
+
+// JsonDefaults defines the json schema of the options shared by a collection
+// of routes, route options have preference over them. Default middlewares are
+// executed before the route ones.
+type JsonDefaults struct {
+	Host        string            `json:"host"`
+	Schemas     []string          `json:"schemas"`
+	Headers     map[string]string `json:"headers"`
+	Middlewares []string          `json:"middlewares"`
 }
 
 // JsonRoute defines a route json schema
 type JsonRoute struct {
-	Name    string `json:"name"`
-	Method  string `json:"method"`
-	Path  string `json:"path"`
-	Host string `json:"host"`
-	Handler string `json:"handler"`
-	Schemas []string `json:"schemas"`
-	Headers map[string]string `json:"headers"`
-	QueryParams map[string]string `json:"queryParams"`
-	CustomMatcher string `json:"customMatcher"`
+	Name          string            `json:"name"`
+	Method        string            `json:"method"`
+	Path          string            `json:"path"`
+	Host          string            `json:"host"`
+	Handler       string            `json:"handler"`
+	Schemas       []string          `json:"schemas"`
+	Headers       map[string]string `json:"headers"`
+	QueryParams   map[string]string `json:"queryParams"`
+	CustomMatcher string            `json:"customMatcher"`
+	Middlewares   []string          `json:"middlewares"`
+	Cookies       map[string]string `json:"cookies"`
+	RemoteCIDRs   []string          `json:"remoteCIDRs"`
+	Ports         []int             `json:"ports"`
+	Consumes      []string          `json:"consumes"`
+	Produces      []string          `json:"produces"`
 }
 
 // JsonFileLoader type loads routes from Json files
@@ -36,39 +60,157 @@ func (l *JsonFileLoader) Load() []routing.RouteDef {
 
 // FromFile loads a list of routes from one or many Json file paths
 func (l *JsonFileLoader) FromFile(files ...string) error {
+	reader := jsonFileReader{
+		readFile: ioutil.ReadFile,
+		join: func(file, include string) string {
+			if filepath.IsAbs(include) {
+				return filepath.Clean(include)
+			}
+			return filepath.Join(filepath.Dir(file), include)
+		},
+	}
 
 	for _, path := range files {
-		content, err := ioutil.ReadFile(path)
+		absPath, err := filepath.Abs(path)
 		if err != nil {
 			return err
 		}
 
-		var rs JsonRoutes
-		err = json.Unmarshal(content, &rs)
+		fileRoutes, err := reader.load(absPath, jsonScope{}, nil)
 		if err != nil {
 			return err
 		}
 
-		fileRoutes := make([]routing.RouteDef, 0, len(rs.Routes))
-		for _, r := range rs.Routes {
-			fileRoutes = append(fileRoutes, routing.RouteDef{
-				Method:  r.Method,
-				Path:  r.Path,
-				Handler: r.Handler,
-				Options:  routing.RouteDefOptions{
-					Name: r.Name,
-					Host: r.Host,
-					Schemas: r.Schemas,
-					Headers: r.Headers,
-					QueryParams: r.QueryParams,
-					CustomMatcher: r.CustomMatcher,
-				},
-			})
+		l.routes = append(l.routes, fileRoutes...)
+	}
+
+	return nil
+}
+
+// jsonFileReader loads Json route files and the files they include
+type jsonFileReader struct {
+	readFile func(name string) ([]byte, error)
+	join     func(file, include string) string
+	// included records the files loaded through includes, if not nil
+	included map[string]bool
+}
+
+func (r jsonFileReader) load(file string, parent jsonScope, stack []string) ([]routing.RouteDef, error) {
+	for _, visited := range stack {
+		if visited == file {
+			return nil, fmt.Errorf("include cycle detected: %s -> %s", strings.Join(stack, " -> "), file)
 		}
+	}
+	if len(stack) > 0 && r.included != nil {
+		r.included[file] = true
+	}
+	stack = append(stack, file)
 
-		l.routes = append(l.routes, fileRoutes...)
+	content, err := r.readFile(file)
+	if err != nil {
+		return nil, err
+	}
 
+	var rs JsonRoutes
+	err = json.Unmarshal(content, &rs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
 	}
 
-	return nil
-}
\ No newline at end of file
+	scope := parent.nest(rs)
+
+	fileRoutes := make([]routing.RouteDef, 0, len(rs.Routes))
+	for _, route := range rs.Routes {
+		fileRoutes = append(fileRoutes, scope.apply(route))
+	}
+
+	for _, include := range rs.Include {
+		includedRoutes, err := r.load(r.join(file, include), scope, stack)
+		if err != nil {
+			return nil, err
+		}
+
+		fileRoutes = append(fileRoutes, includedRoutes...)
+	}
+
+	return fileRoutes, nil
+}
+
+// jsonScope holds the prefix and defaults inherited by the routes of a file
+type jsonScope struct {
+	prefix   string
+	defaults JsonDefaults
+}
+
+func (s jsonScope) nest(rs JsonRoutes) jsonScope {
+	defaults := s.defaults
+	if rs.Defaults.Host != "" {
+		defaults.Host = rs.Defaults.Host
+	}
+	if len(rs.Defaults.Schemas) > 0 {
+		defaults.Schemas = rs.Defaults.Schemas
+	}
+	defaults.Headers = mergeStringMaps(defaults.Headers, rs.Defaults.Headers)
+	defaults.Middlewares = mergeStringSlices(defaults.Middlewares, rs.Defaults.Middlewares)
+
+	return jsonScope{prefix: s.prefix + rs.Prefix, defaults: defaults}
+}
+
+func (s jsonScope) apply(r JsonRoute) routing.RouteDef {
+	host := r.Host
+	if host == "" {
+		host = s.defaults.Host
+	}
+
+	schemas := r.Schemas
+	if len(schemas) == 0 {
+		schemas = s.defaults.Schemas
+	}
+
+	return routing.RouteDef{
+		Method:  r.Method,
+		Path:    s.prefix + r.Path,
+		Handler: r.Handler,
+		Options: routing.RouteDefOptions{
+			Name:          r.Name,
+			Host:          host,
+			Schemas:       schemas,
+			Headers:       mergeStringMaps(s.defaults.Headers, r.Headers),
+			QueryParams:   r.QueryParams,
+			CustomMatcher: r.CustomMatcher,
+			Middlewares:   mergeStringSlices(s.defaults.Middlewares, r.Middlewares),
+			Cookies:       r.Cookies,
+			RemoteCIDRs:   r.RemoteCIDRs,
+			Ports:         r.Ports,
+			Consumes:      r.Consumes,
+			Produces:      r.Produces,
+		},
+	}
+}
+
+func mergeStringMaps(base, override map[string]string) map[string]string {
+	if len(base) == 0 {
+		return override
+	}
+
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+func mergeStringSlices(base, tail []string) []string {
+	if len(base) == 0 {
+		return tail
+	}
+
+	merged := make([]string, 0, len(base)+len(tail))
+	merged = append(merged, base...)
+
+	return append(merged, tail...)
+}
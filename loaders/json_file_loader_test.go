@@ -2,7 +2,11 @@ package loaders
 
 import (
 	. "github.com/golossus/routing"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -54,9 +58,90 @@ func TestJsonFileLoader_LoadFile(t *testing.T) {
 			Headers:       map[string]string{"X-Dummy": "dummy"},
 			QueryParams:   map[string]string{"offset": "2"},
 			CustomMatcher: "",
+			Middlewares:   []string{"auth"},
+			Cookies:       map[string]string{"deployment": "blue"},
+			RemoteCIDRs:   []string{"10.0.0.0/8"},
+			Ports:         []int{8080},
+			Consumes:      []string{"application/json"},
+			Produces:      []string{"application/json"},
 		},
 	}
 	if !reflect.DeepEqual(routes[2], expected) {
 		t.Errorf("route %v not equals to %v", routes[2], expected)
 	}
 }
+
+func TestJsonFileLoader_LoadFile_AppliesPrefixAndDefaultsToIncludedFiles(t *testing.T) {
+
+	loader := JsonFileLoader{}
+	err := loader.FromFile("../fixtures/includes/main.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routes := loader.Load()
+	if len(routes) != 2 {
+		t.Fatalf("routes length doesn't match")
+	}
+
+	expected := RouteDef{
+		Method:  "GET",
+		Handler: "get.users.handler",
+		Path:    "/api/users",
+		Options: RouteDefOptions{
+			Name:        "get.users",
+			Host:        "api.domain.com",
+			Schemas:     []string{"https"},
+			Headers:     map[string]string{"X-Api": "v1"},
+			Middlewares: []string{"cors"},
+		},
+	}
+	if !reflect.DeepEqual(routes[0], expected) {
+		t.Errorf("route %v not equals to %v", routes[0], expected)
+	}
+
+	expected = RouteDef{
+		Method:  "GET",
+		Handler: "get.admin.users.handler",
+		Path:    "/api/admin/users",
+		Options: RouteDefOptions{
+			Name:        "get.admin.users",
+			Host:        "admin.domain.com",
+			Schemas:     []string{"https"},
+			Headers:     map[string]string{"X-Api": "v2", "X-Admin": "true"},
+			Middlewares: []string{"cors", "auth"},
+		},
+	}
+	if !reflect.DeepEqual(routes[1], expected) {
+		t.Errorf("route %v not equals to %v", routes[1], expected)
+	}
+}
+
+func TestJsonFileLoader_LoadFile_FailsWhenIncludesHaveCycles(t *testing.T) {
+
+	loader := JsonFileLoader{}
+	err := loader.FromFile("../fixtures/cycle/a.json")
+	if err == nil || !strings.Contains(err.Error(), "include cycle detected") {
+		t.Errorf("include cycle error expected but got %v", err)
+	}
+}
+
+func TestJsonFileLoader_LoadFile_FailsWhenIncludeDoesNotExist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "routes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "routes.json")
+	err = ioutil.WriteFile(file, []byte(`{"include": ["missing.json"]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loader := JsonFileLoader{}
+	err = loader.FromFile(file)
+	if err == nil {
+		t.Error("error expected when included file does not exist")
+	}
+}
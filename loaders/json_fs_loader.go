@@ -0,0 +1,103 @@
+//go:build go1.16
+// +build go1.16
+
+package loaders
+
+import (
+	"fmt"
+	"github.com/golossus/routing"
+	"io/fs"
+	"path"
+)
+
+const jsonFileExt = ".json"
+
+// FromFS loads a list of routes from the Json files of a file system, like an
+// embed.FS, matching one or many glob patterns. Patterns matching directories
+// load all the Json files inside them. Files are loaded in lexical order and
+// their includes are resolved within the same file system. Files included by
+// other ones are only loaded through their includes, with the prefix and
+// defaults of the files including them.
+func (l *JsonFileLoader) FromFS(fsys fs.FS, patterns ...string) error {
+	reader := jsonFileReader{
+		readFile: func(name string) ([]byte, error) {
+			return fs.ReadFile(fsys, name)
+		},
+		join: func(file, include string) string {
+			return path.Join(path.Dir(file), include)
+		},
+		included: make(map[string]bool),
+	}
+
+	var files []string
+	matched := make(map[string]bool)
+	for _, pattern := range patterns {
+		patternFiles, err := globFS(fsys, pattern)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range patternFiles {
+			if !matched[file] {
+				matched[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	routes := make(map[string][]routing.RouteDef, len(files))
+	for _, file := range files {
+		fileRoutes, err := reader.load(file, jsonScope{}, nil)
+		if err != nil {
+			return err
+		}
+		routes[file] = fileRoutes
+	}
+
+	for _, file := range files {
+		if !reader.included[file] {
+			l.routes = append(l.routes, routes[file]...)
+		}
+	}
+
+	return nil
+}
+
+func globFS(fsys fs.FS, pattern string) ([]string, error) {
+	matches, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no files match pattern %s", pattern)
+	}
+
+	files := make([]string, 0, len(matches))
+	for _, match := range matches {
+		info, err := fs.Stat(fsys, match)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, match)
+			continue
+		}
+
+		err = fs.WalkDir(fsys, match, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && path.Ext(p) == jsonFileExt {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,166 @@
+//go:build go1.16
+// +build go1.16
+
+package loaders
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+func routeFile(method, name string) *fstest.MapFile {
+	return &fstest.MapFile{
+		Data: []byte(`{"routes": [{"name": "` + name + `", "method": "` + method + `", "path": "/users", "handler": "h"}]}`),
+	}
+}
+
+func assertRouteNames(t *testing.T, loader JsonFileLoader, names ...string) {
+	routes := loader.Load()
+	if len(routes) != len(names) {
+		t.Fatalf("routes length %d doesn't match %d", len(routes), len(names))
+	}
+
+	for i, name := range names {
+		if routes[i].Options.Name != name {
+			t.Errorf("route at index %d is %s instead of %s", i, routes[i].Options.Name, name)
+		}
+	}
+}
+
+func TestJsonFileLoader_FromFS_LoadsGlobPatterns(t *testing.T) {
+	fsys := fstest.MapFS{
+		"routes/b.json": routeFile("POST", "b"),
+		"routes/a.json": routeFile("GET", "a"),
+		"routes/c.yml":  routeFile("PUT", "c"),
+		"other.json":    routeFile("PUT", "other"),
+	}
+
+	loader := JsonFileLoader{}
+	err := loader.FromFS(fsys, "routes/*.json", "other.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertRouteNames(t, loader, "a", "b", "other")
+}
+
+func TestJsonFileLoader_FromFS_LoadsDirectoriesRecursively(t *testing.T) {
+	fsys := fstest.MapFS{
+		"routes/users/b.json": routeFile("POST", "users.b"),
+		"routes/users/a.json": routeFile("GET", "users.a"),
+		"routes/admin.json":   routeFile("GET", "admin"),
+		"routes/README.md":    &fstest.MapFile{Data: []byte("not routes")},
+	}
+
+	loader := JsonFileLoader{}
+	err := loader.FromFS(fsys, "routes", "routes/admin.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertRouteNames(t, loader, "admin", "users.a", "users.b")
+}
+
+func TestJsonFileLoader_FromFS_LoadsFromDirFS(t *testing.T) {
+	loader := JsonFileLoader{}
+	err := loader.FromFS(os.DirFS("../fixtures"), "routes*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertRouteNames(t, loader, "get.users", "post.users", "put.users")
+}
+
+func TestJsonFileLoader_FromFS_FailsWhenPatternMatchesNothing(t *testing.T) {
+	loader := JsonFileLoader{}
+	err := loader.FromFS(fstest.MapFS{}, "routes/*.json")
+	if err == nil {
+		t.Error("error expected when no file matches")
+	}
+}
+
+func TestJsonFileLoader_FromFS_FailsWhenPatternIsMalformed(t *testing.T) {
+	loader := JsonFileLoader{}
+	err := loader.FromFS(fstest.MapFS{}, "routes/[.json")
+	if err == nil {
+		t.Error("error expected when pattern is malformed")
+	}
+}
+
+func TestJsonFileLoader_FromFS_FailsWhenJsonIsInvalid(t *testing.T) {
+	fsys := fstest.MapFS{
+		"routes.json": &fstest.MapFile{Data: []byte(`{"routes": [`)},
+	}
+
+	loader := JsonFileLoader{}
+	err := loader.FromFS(fsys, "routes.json")
+	if err == nil {
+		t.Error("error expected when json is invalid")
+	}
+}
+
+func TestJsonFileLoader_FromFS_ResolvesIncludesWithinFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"routes/main.json": &fstest.MapFile{
+			Data: []byte(`{"prefix": "/api", "include": ["users/users.json"], "routes": [{"name": "main", "method": "GET", "path": "/", "handler": "h"}]}`),
+		},
+		"routes/users/users.json": &fstest.MapFile{
+			Data: []byte(`{"prefix": "/users", "defaults": {"host": "users.com"}, "include": ["../shared.json"], "routes": [{"name": "users", "method": "GET", "path": "/{id}", "handler": "h"}]}`),
+		},
+		"routes/shared.json": routeFile("GET", "shared"),
+	}
+
+	loader := JsonFileLoader{}
+	err := loader.FromFS(fsys, "routes/main.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertRouteNames(t, loader, "main", "users", "shared")
+
+	routes := loader.Load()
+	if routes[1].Path != "/api/users/{id}" || routes[1].Options.Host != "users.com" {
+		t.Errorf("route %v does not inherit prefix and defaults", routes[1])
+	}
+	if routes[2].Path != "/api/users/users" || routes[2].Options.Host != "users.com" {
+		t.Errorf("route %v does not inherit prefix and defaults", routes[2])
+	}
+}
+
+func TestJsonFileLoader_FromFS_SkipsFilesLoadedThroughIncludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"routes/a.json": routeFile("GET", "a"),
+		"routes/main.json": &fstest.MapFile{
+			Data: []byte(`{"prefix": "/api", "include": ["users.json", "a.json"]}`),
+		},
+		"routes/users.json": routeFile("POST", "users"),
+	}
+
+	loader := JsonFileLoader{}
+	err := loader.FromFS(fsys, "routes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertRouteNames(t, loader, "users", "a")
+
+	for _, route := range loader.Load() {
+		if route.Path != "/api/users" {
+			t.Errorf("route %v is not loaded through the include", route)
+		}
+	}
+}
+
+func TestJsonFileLoader_FromFS_FailsWhenIncludesHaveCycles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.json": &fstest.MapFile{Data: []byte(`{"include": ["b.json"]}`)},
+		"b.json": &fstest.MapFile{Data: []byte(`{"include": ["./a.json"]}`)},
+	}
+
+	loader := JsonFileLoader{}
+	err := loader.FromFS(fsys, "a.json")
+	if err == nil {
+		t.Error("error expected when includes have cycles")
+	}
+}
@@ -0,0 +1,168 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LogEntry describes a request served, as recorded by the Logger middleware
+type LogEntry struct {
+	Time      time.Time     `json:"time"`
+	Method    string        `json:"method"`
+	Route     string        `json:"route,omitempty"`
+	Template  string        `json:"template,omitempty"`
+	Path      string        `json:"path"`
+	Proto     string        `json:"proto"`
+	Status    int           `json:"status"`
+	Bytes     int64         `json:"bytes"`
+	Duration  time.Duration `json:"duration"`
+	RemoteIP  string        `json:"remote_ip"`
+	RequestID string        `json:"request_id,omitempty"`
+}
+
+// LogFormatter formats a LogEntry as a line of the access log, without the
+// trailing new line
+type LogFormatter func(entry LogEntry) string
+
+// StructuredLogger is the interface of structured loggers, like *slog.Logger,
+// to log the entries as key-value pairs to
+type StructuredLogger interface {
+	InfoContext(ctx context.Context, msg string, args ...interface{})
+}
+
+// LoggerOptions configures the Logger middleware
+type LoggerOptions struct {
+	// Output is where the formatted entries are written to, it defaults to
+	// os.Stderr and is ignored when a StructuredLogger is set
+	Output io.Writer
+	// Formatter formats the entries written to Output, it defaults to
+	// CommonLogFormatter
+	Formatter LogFormatter
+	// StructuredLogger logs the entries as key-value pairs instead of
+	// writing them to Output
+	StructuredLogger StructuredLogger
+	// ClientIPResolver resolves the remote IP of the requests, the address of
+	// the connection is used when nil
+	ClientIPResolver *ClientIPResolver
+}
+
+// Logger returns a Middleware logging the requests served with their method,
+// matched route name and path template, status, bytes written, duration,
+// remote IP and request ID. When it wraps a whole Router, the route is the one matched by it.
+func Logger(options ...LoggerOptions) Middleware {
+	opts := LoggerOptions{}
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.Output == nil {
+		opts.Output = os.Stderr
+	}
+	if opts.Formatter == nil {
+		opts.Formatter = CommonLogFormatter
+	}
+
+	var mu sync.Mutex
+	log := func(r *http.Request, entry LogEntry) {
+		if opts.StructuredLogger != nil {
+			opts.StructuredLogger.InfoContext(r.Context(), "request",
+				"method", entry.Method,
+				"route", entry.Route,
+				"template", entry.Template,
+				"path", entry.Path,
+				"proto", entry.Proto,
+				"status", entry.Status,
+				"bytes", entry.Bytes,
+				"duration", entry.Duration,
+				"remote_ip", entry.RemoteIP,
+				"request_id", entry.RequestID,
+			)
+			return
+		}
+
+		line := opts.Formatter(entry) + "\n"
+
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = io.WriteString(opts.Output, line)
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			w, rw := wrapResponseWriter(w)
+			r, _ = withRequestSlot(r)
+
+			next(w, r)
+
+			entry := LogEntry{
+				Time:      start,
+				Method:    r.Method,
+				Path:      r.URL.RequestURI(),
+				Proto:     r.Proto,
+				Status:    rw.Status(),
+				Bytes:     rw.Written(),
+				Duration:  time.Since(start),
+				RemoteIP:  remoteIP(r, opts.ClientIPResolver),
+				RequestID: GetRequestID(r),
+			}
+			if entry.Status == 0 {
+				entry.Status = http.StatusOK
+			}
+			if leaf := matchedRoute(r); leaf != nil {
+				entry.Route = leaf.name
+				entry.Template = leaf.template()
+			}
+
+			log(r, entry)
+		}
+	}
+}
+
+// CommonLogFormatter formats the entries in the Common Log Format
+func CommonLogFormatter(entry LogEntry) string {
+	remoteIP := entry.RemoteIP
+	if remoteIP == "" {
+		remoteIP = "-"
+	}
+
+	bytes := "-"
+	if entry.Bytes > 0 {
+		bytes = strconv.FormatInt(entry.Bytes, 10)
+	}
+
+	return fmt.Sprintf("%s - - [%s] \"%s %s %s\" %d %s",
+		remoteIP,
+		entry.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		entry.Method,
+		entry.Path,
+		entry.Proto,
+		entry.Status,
+		bytes,
+	)
+}
+
+// JSONLogFormatter formats the entries as JSON objects
+func JSONLogFormatter(entry LogEntry) string {
+	b, _ := json.Marshal(entry)
+	return string(b)
+}
+
+func remoteIP(r *http.Request, resolver *ClientIPResolver) string {
+	ip := parseHostIP(r.RemoteAddr)
+	if resolver != nil {
+		ip = resolver.ClientIP(r)
+	}
+
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
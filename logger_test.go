@@ -0,0 +1,90 @@
+package routing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testStructuredLogger struct {
+	msg  string
+	args []interface{}
+}
+
+func (l *testStructuredLogger) InfoContext(ctx context.Context, msg string, args ...interface{}) {
+	l.msg, l.args = msg, args
+}
+
+func TestLogger_WritesCommonLogFormatEntries(t *testing.T) {
+	var out bytes.Buffer
+
+	mainRouter := Router{}
+	handler := NewMiddlewarePipe().Next(Logger(LoggerOptions{Output: &out})).Then(testHandlerFunc)
+	_ = mainRouter.Get("/users/{id}", handler, MatchingOptions{Name: "users.show"})
+
+	req, _ := http.NewRequest("GET", "/users/1?expand=true", nil)
+	req.RemoteAddr = "203.0.113.1:1234"
+	mainRouter.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := out.String()
+	assertTrue(t, strings.HasPrefix(line, "203.0.113.1 - - ["))
+	assertTrue(t, strings.HasSuffix(line, "] \"GET /users/1?expand=true HTTP/1.1\" 200 8\n"))
+}
+
+func TestLogger_RecordsRouteWhenWrappingTheRouter(t *testing.T) {
+	var out bytes.Buffer
+
+	mainRouter := Router{}
+	_ = mainRouter.Post("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}, MatchingOptions{Name: "users.create"})
+
+	resolver, _ := NewClientIPResolver("10.0.0.0/8")
+	handler := Logger(LoggerOptions{Output: &out, Formatter: JSONLogFormatter, ClientIPResolver: resolver})(mainRouter.ServeHTTP)
+
+	req, _ := http.NewRequest("POST", "/users/1", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	handler(httptest.NewRecorder(), req)
+
+	var entry LogEntry
+	assertNil(t, json.Unmarshal(out.Bytes(), &entry))
+	assertStringEqual(t, "POST", entry.Method)
+	assertStringEqual(t, "users.create", entry.Route)
+	assertStringEqual(t, "/users/{id}", entry.Template)
+	assertStringEqual(t, "/users/1", entry.Path)
+	assertEqual(t, http.StatusCreated, entry.Status)
+	assertEqual(t, 0, int(entry.Bytes))
+	assertStringEqual(t, "198.51.100.1", entry.RemoteIP)
+}
+
+func TestLogger_LogsToStructuredLogger(t *testing.T) {
+	logger := &testStructuredLogger{}
+
+	handler := Logger(LoggerOptions{StructuredLogger: logger})(testHandlerFunc)
+
+	req, _ := http.NewRequest("GET", "/users", nil)
+	handler(httptest.NewRecorder(), req)
+
+	assertStringEqual(t, "request", logger.msg)
+	assertStringEqual(t, "[method GET route  template  path /users]", fmt.Sprint(logger.args[:8]))
+}
+
+func TestCommonLogFormatter(t *testing.T) {
+	entry := LogEntry{
+		Time:     time.Date(2020, time.March, 1, 10, 0, 0, 0, time.UTC),
+		Method:   "GET",
+		Path:     "/users",
+		Proto:    "HTTP/1.1",
+		Status:   http.StatusNotFound,
+		RemoteIP: "",
+	}
+
+	assertStringEqual(t, `- - - [01/Mar/2020:10:00:00 +0000] "GET /users HTTP/1.1" 404 -`, CommonLogFormatter(entry))
+}
@@ -1,7 +1,9 @@
 package routing
 
 import (
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -11,26 +13,7 @@ type matcher func(r *http.Request) (bool, *node)
 // request and allow a route to be found or not in the Router
 type CustomMatcher func(r *http.Request) bool
 
-func byHost(host string) (matcher, error) {
-	parser := newParser("/" + strings.ToLower(host))
-	_, err := parser.parse()
-	if err != nil {
-		return nil, err
-	}
-
-	root, leaf := createTreeFromChunks(parser.chunks)
-	leaf.handler = func(writer http.ResponseWriter, request *http.Request) {}
-
-	return func(r *http.Request) (bool, *node) {
-		if r == nil {
-			return false, leaf
-		}
-
-		return nil != find(root, "/"+r.Host, r), leaf
-	}, nil
-}
-
-func bySchemas(schemas ...string) (matcher, error) {
+func bySchemas(resolver *ClientIPResolver, schemas ...string) (matcher, error) {
 
 	t := &tree{}
 
@@ -48,39 +31,162 @@ func bySchemas(schemas ...string) (matcher, error) {
 			return false, t.root
 		}
 
-		leaf := find(t.root, "/"+r.URL.Scheme, r)
+		leaf := find(t.root, "/"+requestScheme(r, resolver), r)
 		return nil != leaf, leaf
 	}, nil
 }
 
-func byHeaders(headers map[string]string) matcher {
+// valuesMatcher matches request values, like headers or query parameters,
+// by name against value patterns
+type valuesMatcher struct {
+	patterns map[string]*valuePattern
+	values   func(r *http.Request, name string) []string
+}
 
-	return func(r *http.Request) (bool, *node) {
-		if r == nil || len(headers) > len(r.Header) {
+func newValuesMatcher(templates map[string]string, values func(r *http.Request, name string) []string) (*valuesMatcher, error) {
+	patterns := make(map[string]*valuePattern, len(templates))
+	for name, template := range templates {
+		pattern, err := newValuePattern(template)
+		if err != nil {
+			return nil, err
+		}
+		patterns[name] = pattern
+	}
+
+	return &valuesMatcher{patterns: patterns, values: values}, nil
+}
+
+func (m *valuesMatcher) match(r *http.Request) (bool, *node) {
+	if r == nil {
+		return false, nil
+	}
+
+	for name, pattern := range m.patterns {
+		if ok, _ := pattern.match(m.values(r, name)); !ok {
 			return false, nil
 		}
-		for key, value := range headers {
-			if r.Header.Get(key) != value {
-				return false, nil
-			}
+	}
+	return true, nil
+}
+
+func (m *valuesMatcher) hasParameters() bool {
+	for _, pattern := range m.patterns {
+		if pattern.hasParameters() {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *valuesMatcher) parameters(r *http.Request) URLParameterBag {
+	bag := newURLParameterBag(0)
+	for name, pattern := range m.patterns {
+		if !pattern.hasParameters() {
+			continue
+		}
+		if ok, params := pattern.match(m.values(r, name)); ok {
+			bag = bag.merge(params)
 		}
-		return true, nil
 	}
+	return bag
 }
 
-func byQueryParameters(params map[string]string) matcher {
+func byHeaders(headers map[string]string) (*valuesMatcher, error) {
+	return newValuesMatcher(headers, headerValues)
+}
+
+// headerValues returns all the values of a header, both the ones sent in
+// multiple lines and the elements of comma separated lists
+func headerValues(r *http.Request, name string) []string {
+	lines := r.Header[http.CanonicalHeaderKey(name)]
+
+	values := make([]string, 0, len(lines))
+	values = append(values, lines...)
+	for _, line := range lines {
+		if !strings.Contains(line, ",") {
+			continue
+		}
+		for _, element := range strings.Split(line, ",") {
+			values = append(values, strings.TrimSpace(element))
+		}
+	}
+
+	return values
+}
+
+func byQueryParameters(params map[string]string) (*valuesMatcher, error) {
+	return newValuesMatcher(params, queryValues)
+}
+
+func queryValues(r *http.Request, name string) []string {
+	return r.URL.Query()[name]
+}
+
+func byCookies(cookies map[string]string) (*valuesMatcher, error) {
+	return newValuesMatcher(cookies, cookieValues)
+}
+
+func cookieValues(r *http.Request, name string) []string {
+	values := make([]string, 0, 1)
+	for _, cookie := range r.Cookies() {
+		if cookie.Name == name {
+			values = append(values, cookie.Value)
+		}
+	}
+	return values
+}
+
+func byRemoteCIDRs(resolver *ClientIPResolver, cidrs ...string) (matcher, error) {
+	nets, err := parseCIDRs(cidrs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(r *http.Request) (bool, *node) {
+		if r == nil {
+			return false, nil
+		}
+
+		ip := resolver.ClientIP(r)
+		return ip != nil && containsIP(nets, ip), nil
+	}, nil
+}
+
+func byPorts(ports ...int) matcher {
 
 	return func(r *http.Request) (bool, *node) {
-		if r == nil || len(params) > len(r.URL.Query()) {
+		if r == nil {
 			return false, nil
 		}
-		for key, value := range params {
-			if r.URL.Query().Get(key) != value {
-				return false, nil
+
+		port := requestPort(r)
+		for _, p := range ports {
+			if p == port {
+				return true, nil
 			}
 		}
-		return true, nil
+		return false, nil
+	}
+}
+
+// requestPort returns the port of the request Host if explicit, or the port of
+// the local address the request was received on, or the default port of the
+// connection scheme otherwise
+func requestPort(r *http.Request) int {
+	if _, port, err := net.SplitHostPort(r.Host); err == nil {
+		if p, err := strconv.Atoi(port); err == nil {
+			return p
+		}
+	}
+
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(*net.TCPAddr); ok {
+		return addr.Port
+	}
+
+	if r.TLS != nil {
+		return 443
 	}
+	return 80
 }
 
 func byCustomMatcher(custom func(r *http.Request) bool) matcher {
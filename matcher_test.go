@@ -1,6 +1,9 @@
 package routing
 
 import (
+	"context"
+	"crypto/tls"
+	"net"
 	"net/http"
 	"reflect"
 	"testing"
@@ -16,13 +19,13 @@ func Test_byHost_WithSpecificHosts(t *testing.T) {
 	m, _ := byHost(h)
 	m2, _ := byHost(h2)
 
-	matches, leaf := m(req)
+	matches, _ := m.match(req)
 	assertTrue(t, matches)
-	assertFalse(t, leaf.hasParameters())
+	assertFalse(t, m.hasParameters())
 
-	matches2, leaf2 := m2(req)
+	matches2, _ := m2.match(req)
 	assertFalse(t, matches2)
-	assertFalse(t, leaf2.hasParameters())
+	assertFalse(t, m2.hasParameters())
 }
 
 func Test_byHost_WithDynamicHosts(t *testing.T) {
@@ -32,15 +35,15 @@ func Test_byHost_WithDynamicHosts(t *testing.T) {
 	req.Host = "app.golossus.test2.com"
 
 	m, _ := byHost(h)
-	matches, leaf := m(req)
+	matches, _ := m.match(req)
 	assertTrue(t, matches)
-	assertTrue(t, leaf.hasParameters())
+	assertTrue(t, m.hasParameters())
 
 	req.Host = "app.1234.test2.com"
 	m, _ = byHost(h)
-	matches, leaf = m(req)
+	matches, _ = m.match(req)
 	assertFalse(t, matches)
-	assertTrue(t, leaf.hasParameters())
+	assertTrue(t, m.hasParameters())
 }
 
 func Test_byHost_ReturnsErrorWhenMalformedHost(t *testing.T) {
@@ -64,7 +67,7 @@ func Test_bySchemas_WithValidStaticSchemas(t *testing.T) {
 	ftpReq, _ := http.NewRequest("GET", "/", nil)
 	ftpReq.URL.Scheme = "ftp"
 
-	m, err := bySchemas("http", "https")
+	m, err := bySchemas(nil, "http", "https")
 	assertNotNil(t, m)
 	assertNil(t, err)
 
@@ -91,7 +94,7 @@ func Test_bySchemas_WithValidDynamicSchemas(t *testing.T) {
 	ftpReq, _ := http.NewRequest("GET", "/", nil)
 	ftpReq.URL.Scheme = "ftp"
 
-	m, err := bySchemas("htt{_:ps?}")
+	m, err := bySchemas(nil, "htt{_:ps?}")
 	assertNotNil(t, m)
 	assertNil(t, err)
 
@@ -111,7 +114,7 @@ func Test_bySchemas_WithValidDynamicSchemas(t *testing.T) {
 func Test_bySchemas_ReturnsErrorWhenInvalidSchemaFormat(t *testing.T) {
 	s := "http:"
 
-	_, err := bySchemas(s)
+	_, err := bySchemas(nil, s)
 	assertNotNil(t, err)
 }
 
@@ -122,8 +125,8 @@ func Test_byHeaders_ReturnsFalseWhenInsufficientHeaders(t *testing.T) {
 		"key1": "value1",
 	}
 
-	m := byHeaders(headers)
-	matches, _ := m(req)
+	m, _ := byHeaders(headers)
+	matches, _ := m.match(req)
 	assertFalse(t, matches)
 }
 
@@ -136,8 +139,8 @@ func Test_byHeaders_ReturnsFalseWhenHeaderDoNotMach(t *testing.T) {
 		"key2": "value2",
 	}
 
-	m := byHeaders(headers)
-	matches, _ := m(req)
+	m, _ := byHeaders(headers)
+	matches, _ := m.match(req)
 	assertFalse(t, matches)
 }
 
@@ -150,8 +153,8 @@ func Test_byHeaders_ReturnsTrueWhenHeadersMatch(t *testing.T) {
 		"key2": "value2",
 	}
 
-	m := byHeaders(headers)
-	matches, _ := m(req)
+	m, _ := byHeaders(headers)
+	matches, _ := m.match(req)
 	assertTrue(t, matches)
 }
 
@@ -162,8 +165,8 @@ func Test_byQueryParameters_ReturnsFalseWhenInsufficientQueryParams(t *testing.T
 		"key1": "value1",
 	}
 
-	m := byQueryParameters(params)
-	matches, _ := m(req)
+	m, _ := byQueryParameters(params)
+	matches, _ := m.match(req)
 	assertFalse(t, matches)
 }
 
@@ -174,8 +177,8 @@ func Test_byQueryParameters_ReturnsFalseWhenQueryParamsDoNotMach(t *testing.T) {
 		"key2": "value2",
 	}
 
-	m := byQueryParameters(params)
-	matches, _ := m(req)
+	m, _ := byQueryParameters(params)
+	matches, _ := m.match(req)
 	assertFalse(t, matches)
 }
 
@@ -186,8 +189,8 @@ func Test_byQueryParameters_ReturnsTrueWhenQueryParamsMatch(t *testing.T) {
 		"key2": "value2",
 	}
 
-	m := byQueryParameters(params)
-	matches, _ := m(req)
+	m, _ := byQueryParameters(params)
+	matches, _ := m.match(req)
 	assertTrue(t, matches)
 }
 
@@ -247,3 +250,124 @@ func assertNotNil(t *testing.T, value interface{}) {
 		t.Errorf("%v is nil", value)
 	}
 }
+
+func Test_byHeaders_MatchesValuePatterns(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Add("Accept", "text/html, application/vnd.acme.v2+json")
+	req.Header.Add("X-Tenant", "first")
+	req.Header.Add("X-Tenant", "second")
+	req.Header.Set("X-Token", "")
+
+	m, err := byHeaders(map[string]string{
+		"accept":   "application/vnd.acme.v{version:[0-9]+}+json",
+		"X-Tenant": "second",
+		"X-Token":  "",
+	})
+	assertNil(t, err)
+	assertTrue(t, m.hasParameters())
+
+	matches, _ := m.match(req)
+	assertTrue(t, matches)
+
+	params := m.parameters(req)
+	version, _ := params.GetByName("version")
+	assertStringEqual(t, "2", version)
+
+	req.Header.Del("X-Token")
+	matches, _ = m.match(req)
+	assertFalse(t, matches)
+}
+
+func Test_byHeaders_ReturnsErrorWhenMalformedValue(t *testing.T) {
+	m, err := byHeaders(map[string]string{"Accept": "v{version"})
+	assertNil(t, m)
+	assertNotNil(t, err)
+}
+
+func Test_byQueryParameters_MatchesValuePatterns(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/?page=3&tag=a&tag=b&debug", nil)
+
+	m, err := byQueryParameters(map[string]string{
+		"page":  "{page:[0-9]+}",
+		"tag":   "b",
+		"debug": "",
+	})
+	assertNil(t, err)
+
+	matches, _ := m.match(req)
+	assertTrue(t, matches)
+
+	params := m.parameters(req)
+	page, _ := params.GetByName("page")
+	assertStringEqual(t, "3", page)
+
+	req, _ = http.NewRequest("GET", "/?page=three&tag=b&debug", nil)
+	matches, _ = m.match(req)
+	assertFalse(t, matches)
+}
+
+func Test_byCookies_MatchesValuePatterns(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "deployment", Value: "green-2"})
+
+	m, err := byCookies(map[string]string{"deployment": "{color:blue|green}-{_:[0-9]+}"})
+	assertNil(t, err)
+
+	matches, _ := m.match(req)
+	assertTrue(t, matches)
+
+	params := m.parameters(req)
+	color, _ := params.GetByName("color")
+	assertStringEqual(t, "green", color)
+
+	req, _ = http.NewRequest("GET", "/", nil)
+	matches, _ = m.match(req)
+	assertFalse(t, matches)
+}
+
+func Test_byRemoteCIDRs(t *testing.T) {
+	resolver, _ := NewClientIPResolver("10.0.0.1")
+	m, err := byRemoteCIDRs(resolver, "192.168.0.0/16", "172.16.0.1")
+	assertNil(t, err)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.10.1:5000"
+	matches, _ := m(req)
+	assertTrue(t, matches)
+
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("X-Forwarded-For", "172.16.0.1")
+	matches, _ = m(req)
+	assertTrue(t, matches)
+
+	req.Header.Set("X-Forwarded-For", "8.8.8.8")
+	matches, _ = m(req)
+	assertFalse(t, matches)
+
+	matches, _ = m(nil)
+	assertFalse(t, matches)
+
+	_, err = byRemoteCIDRs(resolver, "192.168.0.0/99")
+	assertNotNil(t, err)
+}
+
+func Test_byPorts(t *testing.T) {
+	m := byPorts(443, 8080)
+
+	req, _ := http.NewRequest("GET", "http://domain.com:8080/", nil)
+	matches, _ := m(req)
+	assertTrue(t, matches)
+
+	req, _ = http.NewRequest("GET", "http://domain.com/", nil)
+	matches, _ = m(req)
+	assertFalse(t, matches)
+
+	req.TLS = &tls.ConnectionState{}
+	matches, _ = m(req)
+	assertTrue(t, matches)
+
+	req, _ = http.NewRequest("GET", "http://domain.com/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, &net.TCPAddr{Port: 8080}))
+	matches, _ = m(req)
+	assertTrue(t, matches)
+}
@@ -0,0 +1,268 @@
+package routing
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricLabels are the labels of the measures of a request. Routes are
+// identified by name and path template, never by path, to keep the number of
+// series bounded.
+type MetricLabels struct {
+	Method   string
+	Route    string
+	Template string
+	// Status is the class of the status code, like 2xx or 5xx
+	Status string
+}
+
+// MetricsSink receives the measures of the Metrics middleware
+type MetricsSink interface {
+	// RequestStarted is called when a request starts being served
+	RequestStarted(method string)
+	// RequestFinished is called when a request was served, with its duration
+	// and the number of bytes of the response body
+	RequestFinished(labels MetricLabels, duration time.Duration, size int64)
+}
+
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// Metrics returns a Middleware measuring the requests in flight, and the
+// count, duration and response size of the requests served, labelled by
+// method, matched route and status class. Non standard methods are labelled
+// as OTHER. Requests whose handler panics are recorded with a 5xx status.
+func Metrics(sink MetricsSink) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			method := r.Method
+			if !knownMethods[method] {
+				method = "OTHER"
+			}
+
+			sink.RequestStarted(method)
+
+			w, rw := wrapResponseWriter(w)
+			r, _ = withRequestSlot(r)
+
+			// the request is recorded as failed when the handler panics, the
+			// panic is not recovered so the middlewares wrapping it handle it
+			completed := false
+			defer func() {
+				status := rw.Status()
+				if !completed {
+					status = http.StatusInternalServerError
+				}
+
+				labels := MetricLabels{Method: method, Status: statusClass(status)}
+				if leaf := matchedRoute(r); leaf != nil {
+					labels.Route = leaf.name
+					labels.Template = leaf.template()
+				}
+
+				sink.RequestFinished(labels, time.Since(start), rw.Written())
+			}()
+
+			next(w, r)
+			completed = true
+		}
+	}
+}
+
+func statusClass(status int) string {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// DefaultDurationBuckets are the default upper bounds in seconds of the
+// request duration histogram buckets
+var DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// DefaultSizeBuckets are the default upper bounds in bytes of the response
+// size histogram buckets
+var DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+
+// PrometheusSink is a MetricsSink keeping the measures in memory and exposing
+// them in the Prometheus text format through its ServeHTTP method, which can
+// be registered as a route like /metrics
+type PrometheusSink struct {
+	mu              sync.Mutex
+	durationBuckets []float64
+	sizeBuckets     []float64
+	inFlight        map[string]int64
+	requests        map[MetricLabels]*requestSeries
+}
+
+type requestSeries struct {
+	count     uint64
+	durations histogram
+	sizes     histogram
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+}
+
+func (h *histogram) observe(buckets []float64, v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+
+	for i, upper := range buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+}
+
+// NewPrometheusSink returns a PrometheusSink with the given duration and size
+// histogram buckets, DefaultDurationBuckets and DefaultSizeBuckets when nil
+func NewPrometheusSink(durationBuckets, sizeBuckets []float64) *PrometheusSink {
+	if durationBuckets == nil {
+		durationBuckets = DefaultDurationBuckets
+	}
+	if sizeBuckets == nil {
+		sizeBuckets = DefaultSizeBuckets
+	}
+
+	durationBuckets = append([]float64{}, durationBuckets...)
+	sizeBuckets = append([]float64{}, sizeBuckets...)
+	sort.Float64s(durationBuckets)
+	sort.Float64s(sizeBuckets)
+
+	return &PrometheusSink{
+		durationBuckets: durationBuckets,
+		sizeBuckets:     sizeBuckets,
+		inFlight:        make(map[string]int64),
+		requests:        make(map[MetricLabels]*requestSeries),
+	}
+}
+
+// RequestStarted increments the requests in flight of the method
+func (s *PrometheusSink) RequestStarted(method string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inFlight[method]++
+}
+
+// RequestFinished decrements the requests in flight of the method and records
+// the request count, duration and response size
+func (s *PrometheusSink) RequestFinished(labels MetricLabels, duration time.Duration, size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inFlight[labels.Method]--
+
+	series, ok := s.requests[labels]
+	if !ok {
+		series = &requestSeries{}
+		s.requests[labels] = series
+	}
+
+	series.count++
+	series.durations.observe(s.durationBuckets, duration.Seconds())
+	series.sizes.observe(s.sizeBuckets, float64(size))
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (s *PrometheusSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	buf := bufio.NewWriter(w)
+	defer buf.Flush()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	methods := make([]string, 0, len(s.inFlight))
+	for method := range s.inFlight {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	writeMetricHeader(buf, "http_requests_in_flight", "gauge", "Number of HTTP requests being served.")
+	for _, method := range methods {
+		fmt.Fprintf(buf, "http_requests_in_flight{method=%s} %d\n", quoteLabel(method), s.inFlight[method])
+	}
+
+	labels := make([]MetricLabels, 0, len(s.requests))
+	for l := range s.requests {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return formatLabels(labels[i]) < formatLabels(labels[j])
+	})
+
+	writeMetricHeader(buf, "http_requests_total", "counter", "Total number of HTTP requests served.")
+	for _, l := range labels {
+		fmt.Fprintf(buf, "http_requests_total{%s} %d\n", formatLabels(l), s.requests[l].count)
+	}
+
+	writeMetricHeader(buf, "http_request_duration_seconds", "histogram", "Duration of the HTTP requests served in seconds.")
+	for _, l := range labels {
+		series := s.requests[l]
+		writeHistogram(buf, "http_request_duration_seconds", formatLabels(l), s.durationBuckets, series.durations, series.count)
+	}
+
+	writeMetricHeader(buf, "http_response_size_bytes", "histogram", "Size of the HTTP response bodies in bytes.")
+	for _, l := range labels {
+		series := s.requests[l]
+		writeHistogram(buf, "http_response_size_bytes", formatLabels(l), s.sizeBuckets, series.sizes, series.count)
+	}
+}
+
+func writeMetricHeader(w *bufio.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeHistogram(w *bufio.Writer, name, labels string, buckets []float64, h histogram, count uint64) {
+	for i, upper := range buckets {
+		fmt.Fprintf(w, "%s_bucket{%s,le=%s} %d\n", name, labels, quoteLabel(formatFloat(upper)), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, count)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, count)
+}
+
+func formatLabels(l MetricLabels) string {
+	return "method=" + quoteLabel(l.Method) +
+		",route=" + quoteLabel(l.Route) +
+		",template=" + quoteLabel(l.Template) +
+		",status=" + quoteLabel(l.Status)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quoteLabel(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
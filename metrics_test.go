@@ -0,0 +1,118 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testMetricsSink struct {
+	started  []string
+	finished []MetricLabels
+	sizes    []int64
+}
+
+func (s *testMetricsSink) RequestStarted(method string) {
+	s.started = append(s.started, method)
+}
+
+func (s *testMetricsSink) RequestFinished(labels MetricLabels, duration time.Duration, size int64) {
+	s.finished = append(s.finished, labels)
+	s.sizes = append(s.sizes, size)
+}
+
+func TestMetrics_RecordsRouteLabelsWhenWrappingTheRouter(t *testing.T) {
+	sink := &testMetricsSink{}
+
+	mainRouter := Router{}
+	_ = mainRouter.Get("/users/{id}", testHandlerFunc, MatchingOptions{Name: "users.show"})
+	handler := NewMiddlewarePipe().Next(Metrics(sink)).Then(mainRouter.ServeHTTP)
+
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	handler(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("POST", "/users/1", nil)
+	handler(httptest.NewRecorder(), req)
+
+	assertEqual(t, 2, len(sink.started))
+	assertEqual(t, 2, len(sink.finished))
+	assertTrue(t, sink.finished[0] == MetricLabels{Method: "GET", Route: "users.show", Template: "/users/{id}", Status: "2xx"})
+	assertTrue(t, sink.finished[1] == MetricLabels{Method: "POST", Status: "4xx"})
+	assertTrue(t, sink.sizes[0] == int64(len("/users/1")))
+}
+
+func TestMetrics_LabelsNonStandardMethodsAsOther(t *testing.T) {
+	sink := &testMetricsSink{}
+
+	handler := NewMiddlewarePipe().Next(Metrics(sink)).Then(testDummyHandlerFunc)
+
+	req, _ := http.NewRequest("PURGE", "/", nil)
+	handler(httptest.NewRecorder(), req)
+
+	assertStringEqual(t, "OTHER", sink.started[0])
+	assertStringEqual(t, "OTHER", sink.finished[0].Method)
+}
+
+func TestPrometheusSink_ExposesMetricsInTextFormat(t *testing.T) {
+	sink := NewPrometheusSink([]float64{0.1, 1}, []float64{10, 100})
+
+	labels := MetricLabels{Method: "GET", Route: "users.show", Template: "/users/{id}", Status: "2xx"}
+	sink.RequestStarted("GET")
+	sink.RequestFinished(labels, 50*time.Millisecond, 8)
+	sink.RequestStarted("GET")
+	sink.RequestFinished(labels, 500*time.Millisecond, 50)
+	sink.RequestStarted("POST")
+
+	mainRouter := Router{}
+	_ = mainRouter.Get("/metrics", sink.ServeHTTP)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	mainRouter.ServeHTTP(rec, req)
+
+	body := rec.Body.String()
+	series := `method="GET",route="users.show",template="/users/{id}",status="2xx"`
+
+	assertStringEqual(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assertStringContains(t, "# TYPE http_requests_in_flight gauge\n", body)
+	assertStringContains(t, `http_requests_in_flight{method="GET"} 0`+"\n", body)
+	assertStringContains(t, `http_requests_in_flight{method="POST"} 1`+"\n", body)
+	assertStringContains(t, "# TYPE http_requests_total counter\n", body)
+	assertStringContains(t, "http_requests_total{"+series+"} 2\n", body)
+	assertStringContains(t, "# TYPE http_request_duration_seconds histogram\n", body)
+	assertStringContains(t, "http_request_duration_seconds_bucket{"+series+`,le="0.1"} 1`+"\n", body)
+	assertStringContains(t, "http_request_duration_seconds_bucket{"+series+`,le="1"} 2`+"\n", body)
+	assertStringContains(t, "http_request_duration_seconds_bucket{"+series+`,le="+Inf"} 2`+"\n", body)
+	assertStringContains(t, "http_request_duration_seconds_sum{"+series+"} 0.55\n", body)
+	assertStringContains(t, "http_request_duration_seconds_count{"+series+"} 2\n", body)
+	assertStringContains(t, "http_response_size_bytes_bucket{"+series+`,le="10"} 1`+"\n", body)
+	assertStringContains(t, "http_response_size_bytes_bucket{"+series+`,le="100"} 2`+"\n", body)
+	assertStringContains(t, "http_response_size_bytes_sum{"+series+"} 58\n", body)
+}
+
+func TestPrometheusSink_EscapesLabelValues(t *testing.T) {
+	assertStringEqual(t, `"a\"b\\c\nd"`, quoteLabel("a\"b\\c\nd"))
+	assertTrue(t, !strings.Contains(formatFloat(1e-3), " "))
+	assertStringEqual(t, "0.005", formatFloat(0.005))
+}
+
+func TestMetrics_RecordsPanicsAsServerErrors(t *testing.T) {
+	sink := &testMetricsSink{}
+
+	mainRouter := Router{}
+	_ = mainRouter.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}, MatchingOptions{Name: "panic"})
+	handler := NewMiddlewarePipe().Next(Recover(), Metrics(sink)).Then(mainRouter.ServeHTTP)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	handler(rec, req)
+
+	assertEqual(t, http.StatusInternalServerError, rec.Code)
+	assertEqual(t, 1, len(sink.started))
+	assertEqual(t, 1, len(sink.finished))
+	assertTrue(t, sink.finished[0] == MetricLabels{Method: "GET", Route: "panic", Template: "/panic", Status: "5xx"})
+}
@@ -19,10 +19,26 @@ func (s *MiddlewarePipe) Next(middleware ...Middleware) *MiddlewarePipe {
 	return s
 }
 
+// Prepend adds middlewares before the ones already in the pipe
+func (s *MiddlewarePipe) Prepend(middleware ...Middleware) *MiddlewarePipe {
+	middlewares := make([]Middleware, 0, len(middleware)+len(s.middlewares))
+	middlewares = append(middlewares, middleware...)
+	s.middlewares = append(middlewares, s.middlewares...)
+	return s
+}
+
 func (s *MiddlewarePipe) Pipe(pipe *MiddlewarePipe) {
 	s.middlewares = append(s.middlewares, pipe.middlewares...)
 }
 
+// Clone returns a copy of the pipe, the middlewares added to any of them
+// later are not added to the other
+func (s *MiddlewarePipe) Clone() *MiddlewarePipe {
+	middlewares := make([]Middleware, len(s.middlewares))
+	copy(middlewares, s.middlewares)
+	return &MiddlewarePipe{middlewares: middlewares}
+}
+
 func (s *MiddlewarePipe) Then(next http.HandlerFunc) http.HandlerFunc {
 	for j := len(s.middlewares) - 1; j >= 0; j-- {
 		next = s.middlewares[j](next)
@@ -30,3 +46,25 @@ func (s *MiddlewarePipe) Then(next http.HandlerFunc) http.HandlerFunc {
 
 	return next
 }
+
+// When returns a Middleware applying the given one only to the requests the
+// matcher matches, like the ones of MustByPath
+func When(matcher CustomMatcher, middleware Middleware) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		wrapped := middleware(next)
+
+		return func(w http.ResponseWriter, r *http.Request) {
+			if matcher(r) {
+				wrapped(w, r)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// Unless returns a Middleware applying the given one only to the requests the
+// matcher does not match
+func Unless(matcher CustomMatcher, middleware Middleware) Middleware {
+	return When(Not(matcher), middleware)
+}
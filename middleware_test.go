@@ -3,6 +3,7 @@ package routing
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -74,3 +75,72 @@ func TestMiddlewarePipe_Then(t *testing.T) {
 	assertStringEqual(t, "https://test.com", response.Header().Get("Access-Control-Allow-Origin"))
 
 }
+
+func TestMiddlewarePipe_Prepend(t *testing.T) {
+	var calls []string
+	middleware := func(name string) Middleware {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next(w, r)
+			}
+		}
+	}
+
+	handler := NewMiddlewarePipe().Next(middleware("b")).Prepend(middleware("a")).Next(middleware("c")).Then(testHandlerFunc)
+
+	request, _ := http.NewRequest("GET", "/", nil)
+	handler(httptest.NewRecorder(), request)
+
+	assertStringEqual(t, "a b c", strings.Join(calls, " "))
+}
+
+func TestMiddlewarePipe_Clone(t *testing.T) {
+	noop := func(handlerFunc http.HandlerFunc) http.HandlerFunc {
+		return handlerFunc
+	}
+
+	pipe := NewMiddlewarePipe().Next(noop, noop)
+	clone := pipe.Clone().Next(noop)
+	pipe.Next(noop, noop)
+
+	assertEqual(t, 4, len(pipe.middlewares))
+	assertEqual(t, 3, len(clone.middlewares))
+}
+
+func TestWhenAndUnless_ApplyMiddlewaresConditionally(t *testing.T) {
+	header := func(handlerFunc http.HandlerFunc) http.HandlerFunc {
+		return func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set("X-Applied", "true")
+			handlerFunc(writer, request)
+		}
+	}
+
+	skipped := MustByPath("/health", "/metrics/{name}")
+	unless := NewMiddlewarePipe().Next(Unless(skipped, header)).Then(testHandlerFunc)
+	when := NewMiddlewarePipe().Next(When(skipped, header)).Then(testHandlerFunc)
+
+	cases := []struct {
+		path    string
+		skipped bool
+	}{
+		{"/health", true},
+		{"/metrics/requests", true},
+		{"/metrics", false},
+		{"/metrics/requests/total", false},
+		{"/users", false},
+	}
+
+	for _, c := range cases {
+		request, _ := http.NewRequest("GET", c.path, nil)
+
+		response := httptest.NewRecorder()
+		unless(response, request)
+		assertEqual(t, http.StatusOK, response.Code)
+		assertTrue(t, (response.Header().Get("X-Applied") == "") == c.skipped)
+
+		response = httptest.NewRecorder()
+		when(response, request)
+		assertTrue(t, (response.Header().Get("X-Applied") == "true") == c.skipped)
+	}
+}
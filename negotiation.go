@@ -0,0 +1,192 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// mediaRange represents a media type, optionally with wildcards, parameters
+// and a quality value as defined in RFC 7231
+type mediaRange struct {
+	typ     string
+	subtype string
+	params  map[string]string
+	q       float64
+}
+
+func parseMediaRange(s string) (mediaRange, error) {
+	mediaType, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		return mediaRange{}, fmt.Errorf("invalid media type %s: %w", s, err)
+	}
+
+	parts := strings.SplitN(mediaType, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || (parts[0] == "*" && parts[1] != "*") {
+		return mediaRange{}, fmt.Errorf("invalid media type %s", s)
+	}
+
+	m := mediaRange{typ: parts[0], subtype: parts[1], params: params, q: 1}
+	if q, ok := params["q"]; ok {
+		delete(params, "q")
+		m.q, err = strconv.ParseFloat(q, 64)
+		if err != nil || m.q < 0 || m.q > 1 {
+			return mediaRange{}, fmt.Errorf("invalid quality value in media type %s", s)
+		}
+	}
+
+	return m, nil
+}
+
+func parseMediaRanges(types ...string) ([]mediaRange, error) {
+	ranges := make([]mediaRange, 0, len(types))
+	for _, t := range types {
+		m, err := parseMediaRange(t)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, m)
+	}
+	return ranges, nil
+}
+
+// parseAccept parses the media ranges of the Accept header, ignoring the
+// malformed ones
+func parseAccept(r *http.Request) []mediaRange {
+	var ranges []mediaRange
+	for _, line := range r.Header["Accept"] {
+		for _, element := range strings.Split(line, ",") {
+			if m, err := parseMediaRange(strings.TrimSpace(element)); err == nil {
+				ranges = append(ranges, m)
+			}
+		}
+	}
+	return ranges
+}
+
+// includes checks if a media type is included in the media range, returning
+// the specificity of the match, the higher the more specific, or -1 otherwise
+func (m mediaRange) includes(t mediaRange) int {
+	specificity := 0
+	if m.typ != "*" {
+		if m.typ != t.typ {
+			return -1
+		}
+		specificity++
+	}
+
+	if m.subtype != "*" {
+		if m.subtype != t.subtype {
+			return -1
+		}
+		specificity++
+	}
+
+	for k, v := range m.params {
+		if t.params[k] != v {
+			return -1
+		}
+		specificity++
+	}
+
+	return specificity
+}
+
+// quality returns the quality value of the most specific accepted media range
+// including the given media type, or 0 if none does
+func quality(accepted []mediaRange, offer mediaRange) float64 {
+	q, specificity := 0.0, -1
+	for _, a := range accepted {
+		if s := a.includes(offer); s > specificity {
+			q, specificity = a.q, s
+		}
+	}
+	return q
+}
+
+// bestQuality returns the highest quality of any of the offered media types
+// for the request Accept header, a request without it accepts any media type
+func bestQuality(r *http.Request, offers []mediaRange) float64 {
+	accepted := parseAccept(r)
+	if len(accepted) == 0 {
+		return 1
+	}
+
+	best := 0.0
+	for _, offer := range offers {
+		if q := quality(accepted, offer); q > best {
+			best = q
+		}
+	}
+	return best
+}
+
+type negotiationKey int
+
+var negotiationCtxKey negotiationKey
+
+// negotiationSkip flags in the request context the negotiation matchers to
+// skip, it is used to tell apart a missing route from a failed negotiation
+type negotiationSkip int
+
+const (
+	skipProduces negotiationSkip = 1 << iota
+	skipConsumes
+)
+
+func withNegotiationSkipped(r *http.Request, skip negotiationSkip) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), negotiationCtxKey, skip))
+}
+
+func isNegotiationSkipped(r *http.Request, skip negotiationSkip) bool {
+	flags, _ := r.Context().Value(negotiationCtxKey).(negotiationSkip)
+	return flags&skip != 0
+}
+
+func byProduces(produces []mediaRange) matcher {
+
+	return func(r *http.Request) (bool, *node) {
+		if r == nil {
+			return false, nil
+		}
+
+		if isNegotiationSkipped(r, skipProduces) {
+			return true, nil
+		}
+
+		return bestQuality(r, produces) > 0, nil
+	}
+}
+
+func byConsumes(consumes []mediaRange) matcher {
+
+	return func(r *http.Request) (bool, *node) {
+		if r == nil {
+			return false, nil
+		}
+
+		if isNegotiationSkipped(r, skipConsumes) {
+			return true, nil
+		}
+
+		contentType := r.Header.Get("Content-Type")
+		if contentType == "" {
+			return r.ContentLength == 0, nil
+		}
+
+		t, err := parseMediaRange(contentType)
+		if err != nil {
+			return false, nil
+		}
+
+		for _, c := range consumes {
+			if c.includes(t) >= 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
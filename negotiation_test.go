@@ -0,0 +1,102 @@
+package routing
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func Test_parseMediaRange_ReturnsErrorWhenMalformed(t *testing.T) {
+	for _, s := range []string{"", "json", "*/json", "application/", "text/html;q=2", "text/html;q=x"} {
+		if _, err := parseMediaRange(s); err == nil {
+			t.Errorf("error expected for media type %s", s)
+		}
+	}
+}
+
+func Test_quality_UsesMostSpecificRange(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "*/*;q=0.1, application/*;q=0.5, application/json;q=0.9, application/json;version=2")
+
+	cases := map[string]float64{
+		"application/json":           0.9,
+		"application/json;version=2": 1,
+		"application/xml":            0.5,
+		"text/html":                  0.1,
+	}
+
+	accepted := parseAccept(req)
+	for offer, expected := range cases {
+		m, _ := parseMediaRange(offer)
+		if q := quality(accepted, m); q != expected {
+			t.Errorf("quality of %s is %v instead of %v", offer, q, expected)
+		}
+	}
+}
+
+func Test_bestQuality_AcceptsAnythingWithoutAcceptHeader(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	offers, _ := parseMediaRanges("application/json")
+
+	if q := bestQuality(req, offers); q != 1 {
+		t.Errorf("quality %v is not 1", q)
+	}
+
+	req.Header.Set("Accept", "text/html, application/json;q=0")
+	if q := bestQuality(req, offers); q != 0 {
+		t.Errorf("quality %v is not 0", q)
+	}
+}
+
+func Test_byProduces(t *testing.T) {
+	produces, _ := parseMediaRanges("application/vnd.acme.v2+json")
+	m := byProduces(produces)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/vnd.acme.v2+json")
+	matches, _ := m(req)
+	assertTrue(t, matches)
+
+	req.Header.Set("Accept", "application/vnd.acme.v1+json")
+	matches, _ = m(req)
+	assertFalse(t, matches)
+
+	matches, _ = m(withNegotiationSkipped(req, skipProduces))
+	assertTrue(t, matches)
+
+	matches, _ = m(nil)
+	assertFalse(t, matches)
+}
+
+func Test_byConsumes(t *testing.T) {
+	consumes, _ := parseMediaRanges("application/json", "text/*")
+	m := byConsumes(consumes)
+
+	req, _ := http.NewRequest("POST", "/", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	matches, _ := m(req)
+	assertTrue(t, matches)
+
+	req.Header.Set("Content-Type", "text/csv")
+	matches, _ = m(req)
+	assertTrue(t, matches)
+
+	req.Header.Set("Content-Type", "application/xml")
+	matches, _ = m(req)
+	assertFalse(t, matches)
+
+	req.Header.Set("Content-Type", "invalid")
+	matches, _ = m(req)
+	assertFalse(t, matches)
+
+	req.Header.Del("Content-Type")
+	matches, _ = m(req)
+	assertFalse(t, matches)
+
+	matches, _ = m(withNegotiationSkipped(req, skipConsumes))
+	assertTrue(t, matches)
+
+	req, _ = http.NewRequest("GET", "/", nil)
+	matches, _ = m(req)
+	assertTrue(t, matches)
+}
@@ -3,6 +3,8 @@ package routing
 import (
 	"net/http"
 	"regexp"
+	"strings"
+	"time"
 )
 
 const (
@@ -10,18 +12,55 @@ const (
 )
 
 type node struct {
-	prefix   string
-	handler  http.HandlerFunc
-	child    *node
-	parent   *node
-	sibling  *node
-	t        int
-	stops    map[byte]*node
-	regexp   *regexp.Regexp
-	w        int
-	matchers []matcher
+	routeAttributes
+	prefix  string
+	child   *node
+	parent  *node
+	sibling *node
+	t       int
+	stops   map[byte]*node
+	regexp  *regexp.Regexp
+	w       int
+	// alternatives are routes sharing the path of the node but with different
+	// matchers, they have no position in the tree and refer to their primary
+	alternatives []*node
+	primary      *node
 }
 
+// routeAttributes are the attributes of the route held by a node, if any,
+// which move along with it when trees are combined
+type routeAttributes struct {
+	handler    http.HandlerFunc
+	matchers   []matcher
+	extractors []extractor
+	produces   []mediaRange
+	name       string
+	cors       *corsPolicy
+	timeout    time.Duration
+	// noETag opts the route out of the ETag middleware
+	noETag bool
+	// errorHandler responds the errors returned by the handler, if any
+	errorHandler ErrorHandler
+	// matching describes the matching options the matchers were built from,
+	// routes with the same path and description replace each other
+	matching string
+	// custom tells whether the route has a CustomMatcher, which can not be
+	// compared, so that it never replaces nor is replaced by other routes
+	custom bool
+	// auto tells whether the route was registered by the router, like the
+	// automatic OPTIONS ones, so that no middleware wraps it
+	auto bool
+}
+
+// replaces reports whether the route of the node replaces the one of another
+// node with the same path instead of being an alternative to it
+func (a *routeAttributes) replaces(o *routeAttributes) bool {
+	return o.handler == nil || (!a.custom && !o.custom && a.matching == o.matching)
+}
+
+// extractor returns the parameters captured by a matcher from a request
+type extractor func(r *http.Request) URLParameterBag
+
 func (n *node) match(request *http.Request) bool {
 	if n.handler == nil {
 		return false
@@ -35,6 +74,73 @@ func (n *node) match(request *http.Request) bool {
 	return true
 }
 
+// resolve returns the node or the alternative route matching the request, the
+// one with the highest rank if more than one does
+func (n *node) resolve(request *http.Request) *node {
+	if len(n.alternatives) == 0 {
+		if n.match(request) {
+			return n
+		}
+		return nil
+	}
+
+	var best *node
+	bestRank := -1.0
+	candidates := append([]*node{n}, n.alternatives...)
+	for _, c := range candidates {
+		if !c.match(request) {
+			continue
+		}
+		if rank := c.rank(request); rank > bestRank {
+			best, bestRank = c, rank
+		}
+	}
+
+	return best
+}
+
+// rank prioritizes routes with matchers over the ones without them, and among
+// routes producing media types, the ones preferred by the request
+func (n *node) rank(request *http.Request) float64 {
+	if len(n.matchers) == 0 {
+		return 0
+	}
+
+	if len(n.produces) == 0 {
+		return 1
+	}
+
+	return 1 + bestQuality(request, n.produces)
+}
+
+// position returns the node holding the position in the tree of the route
+func (n *node) position() *node {
+	if n.primary != nil {
+		return n.primary
+	}
+	return n
+}
+
+// template returns the path template of the route of the node, with variables
+// in the {name} form
+func (n *node) template() string {
+	var chunks []string
+	for p := n.position(); p != nil; p = p.parent {
+		if p.t == nodeTypeDynamic {
+			chunks = append(chunks, "{"+p.prefix+"}")
+			continue
+		}
+		chunks = append(chunks, p.prefix)
+	}
+
+	var b strings.Builder
+	for i := len(chunks) - 1; i >= 0; i-- {
+		b.WriteString(chunks[i])
+	}
+
+	return b.String()
+}
+
 func (n *node) isCatchAll() bool {
 	return n.regexpToString() == catchAllExpression
 }
@@ -56,13 +162,17 @@ func (n *node) regexpToString() string {
 
 func (n *node) hasParameters() bool {
 
+	if len(n.extractors) > 0 {
+		return true
+	}
+
 	for _, m := range n.matchers {
 		if _, hostLeaf := m(nil); hostLeaf != nil && hostLeaf.hasParameters() {
 			return true
 		}
 	}
 
-	parent := n
+	parent := n.position()
 	for parent != nil {
 		if parent.t == nodeTypeDynamic {
 			return true
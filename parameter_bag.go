@@ -57,6 +57,20 @@ func (u URLParameterBag) merge(other URLParameterBag) URLParameterBag {
 	return bag
 }
 
+// without returns the parameters of the bag not named like any of the other
+// bag parameters
+func (u URLParameterBag) without(other URLParameterBag) URLParameterBag {
+	bag := newURLParameterBag(u.capacity)
+
+	for _, param := range u.params {
+		if _, err := other.GetByName(param.name); err != nil {
+			bag.add(param.name, param.value)
+		}
+	}
+
+	return bag
+}
+
 func newURLParameterBag(capacity uint) URLParameterBag {
 	return URLParameterBag{
 		capacity: capacity,
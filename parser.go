@@ -35,7 +35,21 @@ func newParser(path string) *parser {
 	return &parser{lexer: l, chunks: make([]chunk, 0, 3)}
 }
 
+// newValueParser returns a parser for header or query parameter values, which
+// do not start with a slash and can have variables anywhere. The regular
+// expressions of their chunks are not anchored, so that they can be embedded
+// in the one of the whole value.
+func newValueParser(value string) *parser {
+	l := newValueLexer(value)
+
+	return &parser{lexer: l, chunks: make([]chunk, 0, 3)}
+}
+
 func (p *parser) parse() (bool, error) {
+	if p.lexer.values {
+		return p.parseValue()
+	}
+
 	return p.parseStart()
 }
 
@@ -49,21 +63,28 @@ func (p *parser) parseStart() (bool, error) {
 	return p.parseStatic()
 }
 
-func (p *parser) parseVar() (bool, error) {
+// scanVar scans the identifier and the optional regular expression of a
+// variable, up to its closing brace
+func (p *parser) scanVar(anchored bool) (string, *regexp.Regexp, error) {
 	token := p.lexer.scan()
 
 	if !isVarToken(token) {
-		return false, fmt.Errorf("parser error, expected %s but got %s", "var identifier", token.v)
+		return "", nil, fmt.Errorf("parser error, expected %s but got %s", "var identifier", token.v)
 	}
-	p.buf.Write([]byte(token.v))
+	name := token.v
 
 	token = p.lexer.scan()
 
 	var regExp *regexp.Regexp
 	if isRegExpressionToken(token) {
-		rex, err := regexp.Compile(fmt.Sprintf("^%s$", token.v))
+		exp := token.v
+		if anchored {
+			exp = fmt.Sprintf("^%s$", exp)
+		}
+
+		rex, err := regexp.Compile(exp)
 		if err != nil {
-			return false, err
+			return "", nil, err
 		}
 
 		regExp = rex
@@ -71,12 +92,20 @@ func (p *parser) parseVar() (bool, error) {
 	}
 
 	if !isCloseVarToken(token) {
-		return false, fmt.Errorf("parser error, expected %s but got %s", "}", token.v)
+		return "", nil, fmt.Errorf("parser error, expected %s but got %s", "}", token.v)
 	}
-	p.chunks = append(p.chunks, chunk{t: tChunkDynamic, v: p.buf.String(), exp: regExp})
-	p.buf.Reset()
 
-	token = p.lexer.scan()
+	return name, regExp, nil
+}
+
+func (p *parser) parseVar() (bool, error) {
+	name, regExp, err := p.scanVar(true)
+	if err != nil {
+		return false, err
+	}
+	p.chunks = append(p.chunks, chunk{t: tChunkDynamic, v: name, exp: regExp})
+
+	token := p.lexer.scan()
 	if isEndToken(token) {
 		return true, nil
 	}
@@ -117,6 +146,41 @@ func (p *parser) parseStatic() (bool, error) {
 	return false, fmt.Errorf("parser error, unexpected token %s", token.v)
 }
 
+func (p *parser) parseValue() (bool, error) {
+	token := p.lexer.scan()
+
+	if isEndToken(token) {
+		if p.buf.Len() > 0 {
+			p.chunks = append(p.chunks, chunk{t: tChunkStatic, v: p.buf.String()})
+			p.buf.Reset()
+		}
+		return true, nil
+	}
+
+	if isStaticToken(token) {
+		p.buf.Write([]byte(token.v))
+
+		return p.parseValue()
+	}
+
+	if isOpenVarToken(token) {
+		if p.buf.Len() > 0 {
+			p.chunks = append(p.chunks, chunk{t: tChunkStatic, v: p.buf.String()})
+			p.buf.Reset()
+		}
+
+		name, regExp, err := p.scanVar(false)
+		if err != nil {
+			return false, err
+		}
+		p.chunks = append(p.chunks, chunk{t: tChunkDynamic, v: name, exp: regExp})
+
+		return p.parseValue()
+	}
+
+	return false, fmt.Errorf("parser error, unexpected token %s", token.v)
+}
+
 func isSlashToken(t token) bool {
 	return t.t == tSlash
 }
@@ -157,3 +157,17 @@ func TestParser_Parse_CheckChunks(t *testing.T) {
 		}
 	}
 }
+
+func TestParser_Parse_ValueChunks(t *testing.T) {
+	parser := newValueParser("urn:{id:[0-9]+}{name}")
+	valid, err := parser.parse()
+
+	assertTrue(t, valid)
+	assertNil(t, err)
+	assertEqual(t, 3, len(parser.chunks))
+	assertStringEqual(t, "urn:", parser.chunks[0].v)
+	assertStringEqual(t, "id", parser.chunks[1].v)
+	assertStringEqual(t, "[0-9]+", parser.chunks[1].exp.String())
+	assertStringEqual(t, "name", parser.chunks[2].v)
+	assertNil(t, parser.chunks[2].exp)
+}
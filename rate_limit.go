@@ -0,0 +1,266 @@
+package routing
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitResult is the outcome of taking a request from a rate limit
+type RateLimitResult struct {
+	// Allowed tells if the request is within the limit
+	Allowed bool
+	// Limit is the number of requests allowed per period
+	Limit int
+	// Remaining is the number of requests still allowed
+	Remaining int
+	// Reset is the time until the limit is fully available again
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, if denied
+	RetryAfter time.Duration
+}
+
+// RateLimitStore keeps the state of the rate limits by key. Implementations
+// backed by external systems allow to share the limits between instances.
+type RateLimitStore interface {
+	Take(key string) (RateLimitResult, error)
+}
+
+// RateLimitKeyFunc returns the key to rate limit a request by, requests with
+// an empty key are not limited
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitOptions configures the RateLimit middleware
+type RateLimitOptions struct {
+	// Store keeps the state of the limits, like NewTokenBucketStore or
+	// NewSlidingWindowStore do in memory. It is required.
+	Store RateLimitStore
+	// Key returns the key to limit a request by, it defaults to the client IP
+	Key RateLimitKeyFunc
+	// Handler responds to the requests exceeding the limit, it defaults to a
+	// 429 Too Many Requests response
+	Handler http.HandlerFunc
+}
+
+// RateLimit returns a Middleware limiting the requests with the given store
+// and key. Responses carry the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers, and Retry-After when the limit is exceeded. The
+// requests are allowed if the store fails. Used for a whole Router it
+// applies a global limit, used for a route it limits just that route. It
+// panics if the options have no Store.
+func RateLimit(options RateLimitOptions) Middleware {
+	if options.Store == nil {
+		panic("rate limit store can not be nil")
+	}
+
+	if options.Key == nil {
+		options.Key = KeyByClientIP(nil)
+	}
+
+	if options.Handler == nil {
+		options.Handler = func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "429 too many requests", http.StatusTooManyRequests)
+		}
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := options.Key(r)
+			if key == "" {
+				next(w, r)
+				return
+			}
+
+			result, err := options.Store.Take(key)
+			if err != nil {
+				next(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+				options.Handler(w, r)
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+// KeyByClientIP returns a RateLimitKeyFunc keying requests by client IP,
+// resolved by the given resolver or from the connection address if nil
+func KeyByClientIP(resolver *ClientIPResolver) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		return remoteIP(r, resolver)
+	}
+}
+
+// KeyByHeader returns a RateLimitKeyFunc keying requests by a header value
+func KeyByHeader(name string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// KeyByURLParameter returns a RateLimitKeyFunc keying requests by one of the
+// parameters returned by GetURLParameters
+func KeyByURLParameter(name string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		params := GetURLParameters(r)
+		value, _ := params.GetByName(name)
+		return value
+	}
+}
+
+// seconds rounds up a duration to seconds
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// memoryStore keeps rate limit states in memory, evicting the ones idle for
+// longer than the period of the limit
+type memoryStore struct {
+	mu        sync.Mutex
+	period    time.Duration
+	states    map[string]interface{}
+	lastSweep time.Time
+	now       func() time.Time
+	take      func(state interface{}, now time.Time) (interface{}, RateLimitResult)
+	idle      func(state interface{}, now time.Time) bool
+}
+
+func (s *memoryStore) Take(key string) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) > s.period {
+		for k, state := range s.states {
+			if s.idle(state, now) {
+				delete(s.states, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	state, result := s.take(s.states[key], now)
+	s.states[key] = state
+
+	return result, nil
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucketStore returns an in memory RateLimitStore with a token bucket
+// per key, holding up to limit tokens and refilled at limit tokens per period.
+// It panics if the limit or the period are not positive.
+func NewTokenBucketStore(limit int, period time.Duration) RateLimitStore {
+	if limit <= 0 {
+		panic("rate limit must be positive")
+	}
+	if period <= 0 {
+		panic("rate limit period must be positive")
+	}
+
+	rate := float64(limit) / float64(period)
+
+	s := &memoryStore{period: period, states: make(map[string]interface{}), now: time.Now}
+	s.take = func(state interface{}, now time.Time) (interface{}, RateLimitResult) {
+		b, ok := state.(*tokenBucket)
+		if !ok {
+			b = &tokenBucket{tokens: float64(limit), last: now}
+		}
+
+		b.tokens = math.Min(float64(limit), b.tokens+float64(now.Sub(b.last))*rate)
+		b.last = now
+
+		result := RateLimitResult{Limit: limit}
+		if b.tokens >= 1 {
+			b.tokens--
+			result.Allowed = true
+		} else {
+			result.RetryAfter = time.Duration(math.Ceil((1 - b.tokens) / rate))
+		}
+
+		result.Remaining = int(b.tokens)
+		result.Reset = time.Duration(math.Ceil((float64(limit) - b.tokens) / rate))
+
+		return b, result
+	}
+	s.idle = func(state interface{}, now time.Time) bool {
+		return now.Sub(state.(*tokenBucket).last) > period
+	}
+
+	return s
+}
+
+type slidingWindow struct {
+	start    time.Time
+	previous int
+	current  int
+}
+
+// NewSlidingWindowStore returns an in memory RateLimitStore with a sliding
+// window per key, allowing up to limit requests in any window of the given
+// length. The count of the previous window is weighted by its overlap. It
+// panics if the limit or the window are not positive.
+func NewSlidingWindowStore(limit int, window time.Duration) RateLimitStore {
+	if limit <= 0 {
+		panic("rate limit must be positive")
+	}
+	if window <= 0 {
+		panic("rate limit window must be positive")
+	}
+
+	s := &memoryStore{period: window, states: make(map[string]interface{}), now: time.Now}
+	s.take = func(state interface{}, now time.Time) (interface{}, RateLimitResult) {
+		w, ok := state.(*slidingWindow)
+		if !ok {
+			w = &slidingWindow{start: now.Truncate(window)}
+		}
+
+		if elapsed := now.Sub(w.start); elapsed >= 2*window {
+			w.start, w.previous, w.current = now.Truncate(window), 0, 0
+		} else if elapsed >= window {
+			w.start, w.previous, w.current = w.start.Add(window), w.current, 0
+		}
+
+		elapsed := now.Sub(w.start)
+		weight := 1 - float64(elapsed)/float64(window)
+		count := float64(w.previous)*weight + float64(w.current)
+
+		result := RateLimitResult{Limit: limit, Reset: window - elapsed}
+		if count+1 <= float64(limit) {
+			w.current++
+			count++
+			result.Allowed = true
+		} else {
+			result.RetryAfter = window - elapsed
+			if w.previous > 0 {
+				wait := time.Duration(math.Ceil((count + 1 - float64(limit)) / float64(w.previous) * float64(window)))
+				if wait < result.RetryAfter {
+					result.RetryAfter = wait
+				}
+			}
+		}
+
+		result.Remaining = int(math.Max(0, float64(limit)-count))
+
+		return w, result
+	}
+	s.idle = func(state interface{}, now time.Time) bool {
+		return now.Sub(state.(*slidingWindow).start) >= 2*window
+	}
+
+	return s
+}
@@ -0,0 +1,166 @@
+package routing
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(key string) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("unavailable")
+}
+
+func TestNewTokenBucketStore_RefillsTokensOverTime(t *testing.T) {
+	clock := &testClock{now: time.Date(2020, time.March, 1, 10, 0, 0, 0, time.UTC)}
+	store := NewTokenBucketStore(2, 2*time.Second)
+	store.(*memoryStore).now = clock.Now
+
+	result, _ := store.Take("a")
+	assertTrue(t, result.Allowed)
+	assertEqual(t, 1, result.Remaining)
+
+	result, _ = store.Take("a")
+	assertTrue(t, result.Allowed)
+	assertEqual(t, 0, result.Remaining)
+	assertEqual(t, int(2*time.Second), int(result.Reset))
+
+	result, _ = store.Take("a")
+	assertFalse(t, result.Allowed)
+	assertEqual(t, int(time.Second), int(result.RetryAfter))
+
+	result, _ = store.Take("b")
+	assertTrue(t, result.Allowed)
+
+	clock.now = clock.now.Add(time.Second)
+	result, _ = store.Take("a")
+	assertTrue(t, result.Allowed)
+
+	clock.now = clock.now.Add(time.Hour)
+	_, _ = store.Take("c")
+	assertEqual(t, 1, len(store.(*memoryStore).states))
+}
+
+func TestNewSlidingWindowStore_WeightsPreviousWindow(t *testing.T) {
+	clock := &testClock{now: time.Date(2020, time.March, 1, 10, 0, 0, 0, time.UTC)}
+	store := NewSlidingWindowStore(4, time.Minute)
+	store.(*memoryStore).now = clock.Now
+
+	for i := 0; i < 4; i++ {
+		result, _ := store.Take("a")
+		assertTrue(t, result.Allowed)
+		assertEqual(t, 3-i, result.Remaining)
+	}
+
+	result, _ := store.Take("a")
+	assertFalse(t, result.Allowed)
+	assertEqual(t, int(time.Minute), int(result.RetryAfter))
+
+	clock.now = clock.now.Add(90 * time.Second)
+	result, _ = store.Take("a")
+	assertTrue(t, result.Allowed)
+	assertEqual(t, 1, result.Remaining)
+
+	result, _ = store.Take("a")
+	assertTrue(t, result.Allowed)
+	assertEqual(t, 0, result.Remaining)
+
+	result, _ = store.Take("a")
+	assertFalse(t, result.Allowed)
+	assertEqual(t, int(15*time.Second), int(result.RetryAfter))
+
+	clock.now = clock.now.Add(5 * time.Minute)
+	result, _ = store.Take("a")
+	assertTrue(t, result.Allowed)
+	assertEqual(t, 3, result.Remaining)
+}
+
+func TestRateLimit_RespondsTooManyRequests(t *testing.T) {
+	mainRouter := Router{}
+	limit := RateLimit(RateLimitOptions{Store: NewTokenBucketStore(1, time.Minute), Key: KeyByURLParameter("id")})
+	_ = mainRouter.Get("/users/{id}", NewMiddlewarePipe().Next(limit).Then(testHandlerFunc))
+
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusOK, res.Code)
+	assertStringEqual(t, "1", res.Header().Get("RateLimit-Limit"))
+	assertStringEqual(t, "0", res.Header().Get("RateLimit-Remaining"))
+	assertStringEqual(t, "60", res.Header().Get("RateLimit-Reset"))
+
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusTooManyRequests, res.Code)
+	assertStringEqual(t, "60", res.Header().Get("Retry-After"))
+
+	req, _ = http.NewRequest("GET", "/users/2", nil)
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusOK, res.Code)
+}
+
+func TestRateLimit_KeysAndFailures(t *testing.T) {
+	handler := RateLimit(RateLimitOptions{Store: NewSlidingWindowStore(1, time.Minute), Key: KeyByHeader("X-Api-Key")})(testHandlerFunc)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	for i := 0; i < 2; i++ {
+		res := httptest.NewRecorder()
+		handler(res, req)
+		assertEqual(t, http.StatusOK, res.Code)
+		assertStringEqual(t, "", res.Header().Get("RateLimit-Limit"))
+	}
+
+	handler = RateLimit(RateLimitOptions{Store: NewSlidingWindowStore(1, time.Minute)})(testHandlerFunc)
+	req.RemoteAddr = "203.0.113.1:1234"
+	res := httptest.NewRecorder()
+	handler(res, req)
+	assertEqual(t, http.StatusOK, res.Code)
+	res = httptest.NewRecorder()
+	handler(res, req)
+	assertEqual(t, http.StatusTooManyRequests, res.Code)
+
+	handler = RateLimit(RateLimitOptions{Store: failingStore{}})(testHandlerFunc)
+	res = httptest.NewRecorder()
+	handler(res, req)
+	assertEqual(t, http.StatusOK, res.Code)
+}
+
+func TestRateLimit_PanicsWithoutStore(t *testing.T) {
+	defer func() {
+		assertTrue(t, recover() == "rate limit store can not be nil")
+	}()
+
+	RateLimit(RateLimitOptions{})
+	t.Error("RateLimit did not panic")
+}
+
+func TestRateLimitStores_PanicWithoutPositiveLimitOrPeriod(t *testing.T) {
+	cases := []func(){
+		func() { NewTokenBucketStore(0, time.Minute) },
+		func() { NewTokenBucketStore(1, 0) },
+		func() { NewSlidingWindowStore(-1, time.Minute) },
+		func() { NewSlidingWindowStore(1, -time.Minute) },
+	}
+
+	for i, c := range cases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("case %d did not panic", i)
+				}
+			}()
+			c()
+		}()
+	}
+}
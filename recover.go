@@ -0,0 +1,65 @@
+package routing
+
+import (
+	"net/http"
+	"runtime/debug"
+)
+
+// RecoverOptions configures the Recover middleware
+type RecoverOptions struct {
+	// Handler responds to the requests whose handler panicked, it defaults to
+	// a 500 Internal Server Error response
+	Handler http.HandlerFunc
+	// Reporter is called with the request, the recovered value and the stack
+	// trace of every panic. RouteName and GetRequestID return the name of the
+	// route matched and the ID of the request.
+	Reporter func(r *http.Request, recovered interface{}, stack []byte)
+}
+
+// Recover returns a Middleware recovering from the panics of the next
+// handlers. The response is only written if the headers were not sent yet.
+// Panics with http.ErrAbortHandler are propagated to abort the response.
+func Recover(options ...RecoverOptions) Middleware {
+	opts := RecoverOptions{}
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.Handler == nil {
+		opts.Handler = func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w, rw := wrapResponseWriter(w)
+			r, slot := withRequestSlot(r)
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				if opts.Reporter != nil {
+					stack := slot.getPanicStack()
+					if stack == nil {
+						stack = debug.Stack()
+					}
+					opts.Reporter(r, recovered, stack)
+				}
+
+				if rw.Status() == 0 {
+					opts.Handler(w, r)
+				}
+			}()
+
+			next(w, r)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecover_RespondsInternalServerErrorOnPanic(t *testing.T) {
+	var reported interface{}
+	var stack []byte
+	var routeName string
+
+	mainRouter := Router{}
+	handler := NewMiddlewarePipe().Next(Recover(RecoverOptions{
+		Reporter: func(r *http.Request, recovered interface{}, s []byte) {
+			reported, stack, routeName = recovered, s, RouteName(r)
+		},
+	})).Then(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	_ = mainRouter.Get("/users/{id}", handler, MatchingOptions{Name: "users.show"})
+
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+
+	assertEqual(t, http.StatusInternalServerError, res.Code)
+	assertTrue(t, reported == "boom")
+	assertStringEqual(t, "users.show", routeName)
+	assertTrue(t, strings.Contains(string(stack), "recover_test.go"))
+}
+
+func TestRecover_UsesConfiguredHandler(t *testing.T) {
+	handler := Recover(RecoverOptions{
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+	})(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	handler(res, req)
+
+	assertEqual(t, http.StatusServiceUnavailable, res.Code)
+}
+
+func TestRecover_DoesNotWriteWhenHeadersWereSent(t *testing.T) {
+	handler := Recover()(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("partial"))
+		panic("boom")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	handler(res, req)
+
+	assertEqual(t, http.StatusAccepted, res.Code)
+	assertStringEqual(t, "partial", res.Body.String())
+}
+
+func TestRecover_PropagatesAbortHandlerPanics(t *testing.T) {
+	handler := Recover()(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		assertTrue(t, recover() == http.ErrAbortHandler)
+	}()
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler(httptest.NewRecorder(), req)
+}
@@ -0,0 +1,325 @@
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// Registry stores handlers, custom matchers and middlewares to be retrieved by
+// name (canonical or alias) on runtime, mainly when loading routes with
+// Router.Load. It is safe for concurrent use and its zero value is ready to use.
+type Registry struct {
+	mu          sync.RWMutex
+	handlers    map[string]http.HandlerFunc
+	matchers    map[string]CustomMatcher
+	middlewares map[string]Middleware
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the Registry used by the package level functions and
+// by routers without a Registry of their own
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// AddHandler adds an http.HandlerFunc into the registry to be retrieved by name
+// (canonical or alias) on runtime. Anonymous functions have no canonical name
+// and must be registered with at least one alias. It returns an error if any
+// of the names is already registered for another function.
+func (r *Registry) AddHandler(handler http.HandlerFunc, aliases ...string) error {
+	if handler == nil {
+		return fmt.Errorf("handler can not be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.handlers == nil {
+		r.handlers = make(map[string]http.HandlerFunc)
+	}
+
+	names, err := registrationNames("handler", funcName(unsafe.Pointer(&handler)), aliases, func(name string) (string, bool) {
+		h, ok := r.handlers[name]
+		return funcName(unsafe.Pointer(&h)), ok
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		r.handlers[name] = handler
+	}
+
+	return nil
+}
+
+// GetHandler retrieves an http.HandlerFunc given a name from the registry
+func (r *Registry) GetHandler(name string) (http.HandlerFunc, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	handler, ok := r.handlers[name]
+	if !ok {
+		return nil, fmt.Errorf("handler with name %s not registered", name)
+	}
+
+	return handler, nil
+}
+
+// AddCustomMatcher adds a custom route matcher into the registry to be
+// retrieved by name (canonical or alias) on runtime
+func (r *Registry) AddCustomMatcher(m CustomMatcher, aliases ...string) error {
+	if m == nil {
+		return fmt.Errorf("custom matcher can not be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.matchers == nil {
+		r.matchers = make(map[string]CustomMatcher)
+	}
+
+	names, err := registrationNames("custom matcher", funcName(unsafe.Pointer(&m)), aliases, func(name string) (string, bool) {
+		matcher, ok := r.matchers[name]
+		return funcName(unsafe.Pointer(&matcher)), ok
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		r.matchers[name] = m
+	}
+
+	return nil
+}
+
+// GetCustomMatcher retrieves a custom matcher given a name from the registry
+func (r *Registry) GetCustomMatcher(name string) (CustomMatcher, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.matchers[name]
+	if !ok {
+		return nil, fmt.Errorf("custom matcher with name %s not registered", name)
+	}
+
+	return m, nil
+}
+
+// AddMiddleware adds a middleware into the registry to be retrieved by name
+// (canonical or alias) on runtime
+func (r *Registry) AddMiddleware(m Middleware, aliases ...string) error {
+	if m == nil {
+		return fmt.Errorf("middleware can not be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.middlewares == nil {
+		r.middlewares = make(map[string]Middleware)
+	}
+
+	names, err := registrationNames("middleware", funcName(unsafe.Pointer(&m)), aliases, func(name string) (string, bool) {
+		middleware, ok := r.middlewares[name]
+		return funcName(unsafe.Pointer(&middleware)), ok
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		r.middlewares[name] = m
+	}
+
+	return nil
+}
+
+// GetMiddleware retrieves a middleware given a name from the registry
+func (r *Registry) GetMiddleware(name string) (Middleware, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.middlewares[name]
+	if !ok {
+		return nil, fmt.Errorf("middleware with name %s not registered", name)
+	}
+
+	return m, nil
+}
+
+// HandlerNames returns the sorted list of registered handler names
+func (r *Registry) HandlerNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.handlers))
+	for name := range r.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// CustomMatcherNames returns the sorted list of registered custom matcher names
+func (r *Registry) CustomMatcherNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.matchers))
+	for name := range r.matchers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// MiddlewareNames returns the sorted list of registered middleware names
+func (r *Registry) MiddlewareNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.middlewares))
+	for name := range r.middlewares {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// registrationNames returns the names to register a function with, given its
+// runtime name and aliases, skipping the ones already registered for it. The
+// registered function returns the runtime name of the function registered
+// with a name, if any.
+func registrationNames(kind, runtimeName string, aliases []string, registered func(name string) (string, bool)) ([]string, error) {
+	canonical := canonicalName(runtimeName)
+	if canonical == "" && len(aliases) == 0 {
+		return nil, fmt.Errorf("anonymous %s must be registered with an alias", kind)
+	}
+
+	var names []string
+	if canonical != "" {
+		// method values of different receivers share their canonical name,
+		// which keeps referring to the first one registered
+		if existing, ok := registered(canonical); !ok {
+			names = append(names, canonical)
+		} else if existing != runtimeName {
+			return nil, fmt.Errorf("%s with name %s already registered", kind, canonical)
+		}
+	}
+
+	for _, alias := range aliases {
+		existing, ok := registered(alias)
+		if !ok {
+			names = append(names, alias)
+			continue
+		}
+
+		// closures and method values can not be told apart from others of
+		// the same code, so only named functions are known to be the same
+		if canonical == "" || isMethodValue(runtimeName) || existing != runtimeName {
+			return nil, fmt.Errorf("%s with name %s already registered", kind, alias)
+		}
+	}
+
+	return names, nil
+}
+
+// anonymousFuncName matches runtime names of function literals, like
+// main.main.func1 or pkg.glob..func2.1
+var anonymousFuncName = regexp.MustCompile(`\.func\d+(\.\d+)*$`)
+
+// methodValueSuffix is the suffix of the runtime names of method values
+const methodValueSuffix = "-fm"
+
+// HandlerName returns the canonical name of a handler, which is the package
+// path qualified name of the function or method. Anonymous functions have no
+// canonical name so an empty string is returned for them.
+func HandlerName(handler http.HandlerFunc) string {
+	return canonicalName(funcName(unsafe.Pointer(&handler)))
+}
+
+// funcName returns the runtime name of the function a function value refers
+// to, given the address of the value. Function values point to a closure whose
+// first word is the address of the function code, so no reflection is needed.
+func funcName(value unsafe.Pointer) string {
+	closure := *(*unsafe.Pointer)(value)
+	if closure == nil {
+		return ""
+	}
+
+	fn := runtime.FuncForPC(*(*uintptr)(closure))
+	if fn == nil {
+		return ""
+	}
+
+	return fn.Name()
+}
+
+func canonicalName(runtimeName string) string {
+	name := strings.TrimSuffix(runtimeName, methodValueSuffix)
+	if anonymousFuncName.MatchString(name) {
+		return ""
+	}
+
+	return name
+}
+
+func isMethodValue(runtimeName string) bool {
+	return strings.HasSuffix(runtimeName, methodValueSuffix)
+}
+
+// AddHandler adds an http.HandlerFunc into a list of handlers to be retrieved
+// by name (canonical or alias) on runtime
+func AddHandler(handler http.HandlerFunc, aliases ...string) error {
+	return defaultRegistry.AddHandler(handler, aliases...)
+}
+
+// GetHandler retrieves an http.HandlerFunc given a name from the list of handlers
+func GetHandler(name string) (http.HandlerFunc, error) {
+	return defaultRegistry.GetHandler(name)
+}
+
+// AddCustomMatcher adds a customer route matcher into a list of matchers to be
+// retrieved by name (canonical or alias) on runtime
+func AddCustomMatcher(m CustomMatcher, aliases ...string) error {
+	return defaultRegistry.AddCustomMatcher(m, aliases...)
+}
+
+// GetCustomMatcher retrieves a custom matcher given a name from the list of matchers
+func GetCustomMatcher(name string) (CustomMatcher, error) {
+	return defaultRegistry.GetCustomMatcher(name)
+}
+
+// AddMiddleware adds a middleware into a list of middlewares to be retrieved
+// by name (canonical or alias) on runtime
+func AddMiddleware(m Middleware, aliases ...string) error {
+	return defaultRegistry.AddMiddleware(m, aliases...)
+}
+
+// GetMiddleware retrieves a middleware given a name from the list of middlewares
+func GetMiddleware(name string) (Middleware, error) {
+	return defaultRegistry.GetMiddleware(name)
+}
+
+// HandlerNames returns the sorted list of names in the list of handlers
+func HandlerNames() []string {
+	return defaultRegistry.HandlerNames()
+}
@@ -0,0 +1,268 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRegistry_ZeroValueIsReadyToUse(t *testing.T) {
+	var registry Registry
+
+	assertNil(t, registry.AddHandler(testHandlerFunc, "handler"))
+	assertNil(t, registry.AddCustomMatcher(testCustomMatcher, "matcher"))
+	assertNil(t, registry.AddMiddleware(func(next http.HandlerFunc) http.HandlerFunc { return next }, "middleware"))
+
+	h, err := registry.GetHandler("handler")
+	assertNil(t, err)
+	assertNotNil(t, h)
+
+	m, err := registry.GetCustomMatcher("matcher")
+	assertNil(t, err)
+	assertNotNil(t, m)
+
+	mw, err := registry.GetMiddleware("middleware")
+	assertNil(t, err)
+	assertNotNil(t, mw)
+}
+
+func TestRegistry_GetReturnsErrorWhenNotRegistered(t *testing.T) {
+	registry := NewRegistry()
+
+	_, err := registry.GetHandler("handler")
+	assertNotNil(t, err)
+
+	_, err = registry.GetCustomMatcher("matcher")
+	assertNotNil(t, err)
+
+	_, err = registry.GetMiddleware("middleware")
+	assertNotNil(t, err)
+}
+
+func TestRegistry_IsIsolatedFromOtherRegistries(t *testing.T) {
+	registry1 := NewRegistry()
+	registry2 := NewRegistry()
+
+	err := registry1.AddHandler(testHandlerFunc, "isolated.handler")
+	assertNil(t, err)
+
+	_, err = registry1.GetHandler("isolated.handler")
+	assertNil(t, err)
+
+	_, err = registry2.GetHandler("isolated.handler")
+	assertNotNil(t, err)
+
+	_, err = DefaultRegistry().GetHandler("isolated.handler")
+	assertNotNil(t, err)
+}
+
+func TestRegistry_AllowsConcurrentRegistrations(t *testing.T) {
+	registry := NewRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			alias := "handler" + strconv.Itoa(i)
+			assertNil(t, registry.AddHandler(testHandlerFunc, alias))
+			_, _ = registry.GetHandler(alias)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 20; i++ {
+		_, err := registry.GetHandler("handler" + strconv.Itoa(i))
+		assertNil(t, err)
+	}
+}
+
+func TestRouter_Load_UsesRegistryFromConfig(t *testing.T) {
+	registry := NewRegistry()
+	assertNil(t, registry.AddHandler(testHandlerFunc, "config.users.Handler"))
+
+	router := NewRouter(RouterConfig{Registry: registry})
+	loader := sliceLoader{
+		RouteDef{Method: "GET", Path: "/users", Handler: "config.users.Handler"},
+	}
+
+	err := router.Load(&loader)
+	assertNil(t, err)
+	assertPathFound(t, router, "GET", "/users")
+
+	defaultRouter := NewRouter()
+	err = defaultRouter.Load(&loader)
+	assertNotNil(t, err)
+}
+
+func TestRouter_Load_UsesGivenRegistryOverConfig(t *testing.T) {
+	registry := NewRegistry()
+	assertNil(t, registry.AddHandler(testDummyHandlerFunc, "given.users.Handler"))
+
+	router := NewRouter(RouterConfig{Registry: NewRegistry()})
+	loader := sliceLoader{
+		RouteDef{Method: "GET", Path: "/users", Handler: "given.users.Handler"},
+	}
+
+	err := router.Load(&loader, registry)
+	assertNil(t, err)
+
+	r, _ := http.NewRequest("GET", "/users", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assertStringEqual(t, "dummy", w.Body.String())
+}
+
+func testNamedHandlerForm(w http.ResponseWriter, r *http.Request) {}
+
+func testNamedHandlerOf(w http.ResponseWriter, r *http.Request) {}
+
+type testHandlerProvider struct{}
+
+func (p testHandlerProvider) ServeForm(w http.ResponseWriter, r *http.Request) {}
+
+func TestHandlerName_TrimsOnlyMethodValueSuffix(t *testing.T) {
+	assertStringEqual(t, "github.com/golossus/routing.testNamedHandlerForm", HandlerName(testNamedHandlerForm))
+	assertStringEqual(t, "github.com/golossus/routing.testNamedHandlerOf", HandlerName(testNamedHandlerOf))
+	assertStringEqual(t, "github.com/golossus/routing.testHandlerProvider.ServeForm", HandlerName(testHandlerProvider{}.ServeForm))
+}
+
+func TestHandlerName_ReturnsEmptyNameForAnonymousFunctions(t *testing.T) {
+	assertStringEqual(t, "", HandlerName(testHandlerFunc))
+	assertStringEqual(t, "", HandlerName(func(w http.ResponseWriter, r *http.Request) {}))
+}
+
+func TestRegistry_AddHandler_RegistersCanonicalNameAndAliases(t *testing.T) {
+	registry := NewRegistry()
+
+	err := registry.AddHandler(testNamedHandlerForm, "form")
+	assertNil(t, err)
+
+	names := registry.HandlerNames()
+	assertEqual(t, 2, len(names))
+	assertStringEqual(t, "form", names[0])
+	assertStringEqual(t, "github.com/golossus/routing.testNamedHandlerForm", names[1])
+}
+
+func TestRegistry_AddHandler_FailsWhenAnonymousFunctionHasNoAlias(t *testing.T) {
+	registry := NewRegistry()
+
+	err := registry.AddHandler(testHandlerFunc)
+	assertNotNil(t, err)
+	assertEqual(t, 0, len(registry.HandlerNames()))
+}
+
+func TestRegistry_AddHandler_FailsWhenNil(t *testing.T) {
+	registry := NewRegistry()
+
+	err := registry.AddHandler(nil, "nil")
+	assertNotNil(t, err)
+}
+
+func TestRegistry_AddHandler_FailsWhenNameRegisteredForAnotherHandler(t *testing.T) {
+	registry := NewRegistry()
+
+	err := registry.AddHandler(testNamedHandlerForm, "handler")
+	assertNil(t, err)
+
+	err = registry.AddHandler(testNamedHandlerOf, "other", "handler")
+	assertNotNil(t, err)
+
+	_, err = registry.GetHandler("other")
+	assertNotNil(t, err)
+}
+
+func TestRegistry_AddHandler_AllowsSameFunctionUnderItsNames(t *testing.T) {
+	registry := NewRegistry()
+
+	err := registry.AddHandler(testNamedHandlerForm, "form")
+	assertNil(t, err)
+
+	err = registry.AddHandler(testNamedHandlerForm, "form", "other.form")
+	assertNil(t, err)
+	assertEqual(t, 3, len(registry.HandlerNames()))
+
+	err = registry.AddHandler(testNamedHandlerOf, "github.com/golossus/routing.testNamedHandlerForm")
+	assertNotNil(t, err)
+}
+
+type testController struct {
+	body string
+}
+
+func (c *testController) List(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte(c.body))
+}
+
+func TestRegistry_AddHandler_AllowsMethodValuesOfDifferentReceivers(t *testing.T) {
+	registry := NewRegistry()
+	users, posts := &testController{"users"}, &testController{"posts"}
+
+	err := registry.AddHandler(users.List, "users.list")
+	assertNil(t, err)
+
+	err = registry.AddHandler(posts.List, "posts.list")
+	assertNil(t, err)
+
+	err = registry.AddHandler(posts.List, "users.list")
+	assertNotNil(t, err)
+
+	for _, name := range []string{"users.list", "posts.list"} {
+		h, _ := registry.GetHandler(name)
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("GET", "/", nil))
+		assertStringEqual(t, strings.TrimSuffix(name, ".list"), w.Body.String())
+	}
+	assertStringEqual(t, "github.com/golossus/routing.(*testController).List", HandlerName(posts.List))
+}
+
+func TestRegistry_AddHandler_FailsWhenAliasRegisteredForClosureOfSameFunction(t *testing.T) {
+	registry := NewRegistry()
+	newHandler := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(body))
+		}
+	}
+
+	err := registry.AddHandler(newHandler("db1"), "users")
+	assertNil(t, err)
+
+	err = registry.AddHandler(newHandler("db2"), "users")
+	assertNotNil(t, err)
+
+	h, _ := registry.GetHandler("users")
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest("GET", "/", nil))
+	assertStringEqual(t, "db1", w.Body.String())
+}
+
+func TestRegistry_AddCustomMatcherAndMiddleware_FailWhenNameRegisteredForAnotherOne(t *testing.T) {
+	registry := NewRegistry()
+
+	err := registry.AddCustomMatcher(func(r *http.Request) bool { return true }, "matcher")
+	assertNil(t, err)
+	err = registry.AddCustomMatcher(func(r *http.Request) bool { return false }, "matcher")
+	assertNotNil(t, err)
+	assertEqual(t, 1, len(registry.CustomMatcherNames()))
+
+	err = registry.AddMiddleware(func(next http.HandlerFunc) http.HandlerFunc { return next }, "middleware")
+	assertNil(t, err)
+	err = registry.AddMiddleware(func(next http.HandlerFunc) http.HandlerFunc { return nil }, "middleware")
+	assertNotNil(t, err)
+	assertEqual(t, 1, len(registry.MiddlewareNames()))
+}
+
+func TestRouter_Load_ErrorIdentifiesRoute(t *testing.T) {
+	router := NewRouter(RouterConfig{Registry: NewRegistry()})
+	loader := sliceLoader{
+		RouteDef{Method: "GET", Path: "/users", Handler: "missing.Handler"},
+	}
+
+	err := router.Load(&loader)
+	assertNotNil(t, err)
+	assertStringContains(t, "route GET /users", err.Error())
+	assertStringContains(t, "missing.Handler", err.Error())
+}
@@ -0,0 +1,109 @@
+package routing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	defaultRequestIDHeader    = "X-Request-ID"
+	defaultRequestIDMaxLength = 128
+)
+
+type requestIDKey int
+
+var requestIDCtxKey requestIDKey
+
+// RequestIDOptions configures the RequestID middleware
+type RequestIDOptions struct {
+	// Header is the header to read the request ID from and to echo it in, it
+	// defaults to X-Request-ID
+	Header string
+	// Generator generates the IDs of the requests without a valid one, it
+	// defaults to 16 random bytes hex encoded
+	Generator func() string
+	// Validator checks the IDs of the incoming requests, it defaults to IDs of
+	// up to 128 letters, digits and -_.:+/= characters
+	Validator func(id string) bool
+}
+
+// RequestID returns a Middleware reading the ID of the requests from a header,
+// or generating one if missing or invalid, and echoing it in the responses.
+// GetRequestID returns it, also from the middlewares like Logger and Recover
+// wrapping this one.
+func RequestID(options ...RequestIDOptions) Middleware {
+	opts := RequestIDOptions{}
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.Header == "" {
+		opts.Header = defaultRequestIDHeader
+	}
+	if opts.Generator == nil {
+		opts.Generator = generateRequestID
+	}
+	if opts.Validator == nil {
+		opts.Validator = isValidRequestID
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(opts.Header)
+			if id == "" || !opts.Validator(id) {
+				id = opts.Generator()
+			}
+
+			if slot := getRequestSlot(r); slot != nil {
+				slot.setRequestID(id)
+			}
+
+			w.Header().Set(opts.Header, id)
+			next(w, r.WithContext(context.WithValue(r.Context(), requestIDCtxKey, id)))
+		}
+	}
+}
+
+// GetRequestID returns the ID of the request set by the RequestID middleware,
+// or an empty string if there is none
+func GetRequestID(r *http.Request) string {
+	if id, ok := r.Context().Value(requestIDCtxKey).(string); ok {
+		return id
+	}
+
+	if slot := getRequestSlot(r); slot != nil {
+		return slot.getRequestID()
+	}
+	return ""
+}
+
+func generateRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func isValidRequestID(id string) bool {
+	if len(id) > defaultRequestIDMaxLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if !isRequestIDChar(id[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isRequestIDChar(ch byte) bool {
+	switch {
+	case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		return true
+	case ch == '-', ch == '_', ch == '.', ch == ':', ch == '+', ch == '/', ch == '=':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package routing
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID_KeepsValidIncomingIDs(t *testing.T) {
+	var id string
+	handler := RequestID()(func(w http.ResponseWriter, r *http.Request) {
+		id = GetRequestID(r)
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	res := httptest.NewRecorder()
+	handler(res, req)
+
+	assertStringEqual(t, "abc-123", id)
+	assertStringEqual(t, "abc-123", res.Header().Get("X-Request-ID"))
+}
+
+func TestRequestID_GeneratesIDsWhenMissingOrInvalid(t *testing.T) {
+	for _, incoming := range []string{"", "no spaces", "<script>", strings.Repeat("a", 129)} {
+		var id string
+		handler := RequestID()(func(w http.ResponseWriter, r *http.Request) {
+			id = GetRequestID(r)
+		})
+
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Request-ID", incoming)
+		res := httptest.NewRecorder()
+		handler(res, req)
+
+		assertEqual(t, 32, len(id))
+		assertStringEqual(t, id, res.Header().Get("X-Request-ID"))
+	}
+}
+
+func TestRequestID_UsesConfiguredHeaderGeneratorAndValidator(t *testing.T) {
+	handler := RequestID(RequestIDOptions{
+		Header:    "X-Correlation-ID",
+		Generator: func() string { return "generated" },
+		Validator: func(id string) bool { return strings.HasPrefix(id, "corr-") },
+	})(testHandlerFunc)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Correlation-ID", "corr-1")
+	res := httptest.NewRecorder()
+	handler(res, req)
+	assertStringEqual(t, "corr-1", res.Header().Get("X-Correlation-ID"))
+
+	req.Header.Set("X-Correlation-ID", "other")
+	res = httptest.NewRecorder()
+	handler(res, req)
+	assertStringEqual(t, "generated", res.Header().Get("X-Correlation-ID"))
+}
+
+func TestRequestID_IsAvailableToWrappingMiddlewares(t *testing.T) {
+	var out bytes.Buffer
+	var reported, routeName string
+
+	mainRouter := Router{}
+	_ = mainRouter.Get("/users", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}, MatchingOptions{Name: "users"})
+
+	handler := NewMiddlewarePipe().Next(
+		Logger(LoggerOptions{Output: &out, Formatter: JSONLogFormatter}),
+		Recover(RecoverOptions{Reporter: func(r *http.Request, recovered interface{}, stack []byte) {
+			reported, routeName = GetRequestID(r), RouteName(r)
+		}}),
+		RequestID(),
+	).Then(mainRouter.ServeHTTP)
+
+	req, _ := http.NewRequest("GET", "/users", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	res := httptest.NewRecorder()
+	handler(res, req)
+
+	var entry LogEntry
+	assertNil(t, json.Unmarshal(out.Bytes(), &entry))
+	assertEqual(t, http.StatusInternalServerError, entry.Status)
+	assertStringEqual(t, "req-1", entry.RequestID)
+	assertStringEqual(t, "users", entry.Route)
+	assertStringEqual(t, "req-1", reported)
+	assertStringEqual(t, "users", routeName)
+}
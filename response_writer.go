@@ -0,0 +1,161 @@
+package routing
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// wrappingWriter is a response writer of the package wrapping another one. It
+// implements the optional http.Flusher, http.Hijacker and http.Pusher
+// interfaces, which exposeInterfaces hides when the wrapped one does not.
+type wrappingWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+	http.Pusher
+	// wrapped returns the wrapped writer
+	wrapped() http.ResponseWriter
+	// wrapper returns the wrapping writer itself
+	wrapper() wrappingWriter
+}
+
+// wrappingWriterBase is the part of a wrappingWriter always exposed
+type wrappingWriterBase interface {
+	http.ResponseWriter
+	wrapped() http.ResponseWriter
+	wrapper() wrappingWriter
+}
+
+// exposeInterfaces returns the wrapping writer implementing only the optional
+// interfaces implemented by the writer it wraps, so the handlers checking for
+// them see what the connection supports
+func exposeInterfaces(w wrappingWriter) http.ResponseWriter {
+	inner := w.wrapped()
+	_, isFlusher := inner.(http.Flusher)
+	_, isHijacker := inner.(http.Hijacker)
+	_, isPusher := inner.(http.Pusher)
+
+	switch {
+	case isFlusher && isHijacker && isPusher:
+		return w
+	case isFlusher && isHijacker:
+		return struct {
+			wrappingWriterBase
+			http.Flusher
+			http.Hijacker
+		}{w, w, w}
+	case isFlusher && isPusher:
+		return struct {
+			wrappingWriterBase
+			http.Flusher
+			http.Pusher
+		}{w, w, w}
+	case isHijacker && isPusher:
+		return struct {
+			wrappingWriterBase
+			http.Hijacker
+			http.Pusher
+		}{w, w, w}
+	case isFlusher:
+		return struct {
+			wrappingWriterBase
+			http.Flusher
+		}{w, w}
+	case isHijacker:
+		return struct {
+			wrappingWriterBase
+			http.Hijacker
+		}{w, w}
+	case isPusher:
+		return struct {
+			wrappingWriterBase
+			http.Pusher
+		}{w, w}
+	}
+
+	return struct{ wrappingWriterBase }{w}
+}
+
+// responseWriter wraps an http.ResponseWriter to record the status code and
+// the number of bytes written by the handlers
+type responseWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+// wrapResponseWriter returns a response writer recording the status code and
+// the bytes written to w, which keeps implementing the http.Flusher,
+// http.Hijacker and http.Pusher interfaces implemented by w. Writers already
+// wrapped are returned as they are.
+func wrapResponseWriter(w http.ResponseWriter) (http.ResponseWriter, *responseWriter) {
+	if ww, ok := w.(wrappingWriterBase); ok {
+		if rw, ok := ww.wrapper().(*responseWriter); ok {
+			return w, rw
+		}
+	}
+
+	rw := &responseWriter{ResponseWriter: w}
+	return exposeInterfaces(rw), rw
+}
+
+func (w *responseWriter) wrapped() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseWriter) wrapper() wrappingWriter {
+	return w
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
+
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// Status returns the status code sent, or 0 if the headers were not sent yet
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// Written returns the number of bytes of the response body written
+func (w *responseWriter) Written() int64 {
+	return w.written
+}
+
+// Unwrap returns the wrapped http.ResponseWriter
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
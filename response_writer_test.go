@@ -0,0 +1,61 @@
+package routing
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (h hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func TestResponseWriter_RecordsStatusAndWrittenBytes(t *testing.T) {
+	res := httptest.NewRecorder()
+	w, rw := wrapResponseWriter(res)
+	assertEqual(t, 0, rw.Status())
+
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte("hello"))
+
+	assertEqual(t, http.StatusCreated, rw.Status())
+	assertEqual(t, 5, int(rw.Written()))
+	assertTrue(t, rw.Unwrap() == res)
+
+	w2, rw2 := wrapResponseWriter(w)
+	assertTrue(t, w2 == w)
+	assertTrue(t, rw2 == rw)
+}
+
+func TestResponseWriter_WriteImpliesStatusOK(t *testing.T) {
+	w, rw := wrapResponseWriter(httptest.NewRecorder())
+	_, _ = w.Write([]byte("hello"))
+
+	assertEqual(t, http.StatusOK, rw.Status())
+}
+
+func TestResponseWriter_PreservesOptionalInterfaces(t *testing.T) {
+	w, rw := wrapResponseWriter(httptest.NewRecorder())
+	_, isFlusher := w.(http.Flusher)
+	_, isHijacker := w.(http.Hijacker)
+	_, isPusher := w.(http.Pusher)
+	assertTrue(t, isFlusher)
+	assertFalse(t, isHijacker)
+	assertFalse(t, isPusher)
+
+	w.(http.Flusher).Flush()
+	assertEqual(t, http.StatusOK, rw.Status())
+
+	w, rw = wrapResponseWriter(hijackableRecorder{httptest.NewRecorder()})
+	_, isHijacker = w.(http.Hijacker)
+	assertTrue(t, isHijacker)
+
+	_, _, _ = w.(http.Hijacker).Hijack()
+	assertEqual(t, http.StatusSwitchingProtocols, rw.Status())
+}
@@ -1,6 +1,9 @@
 package routing
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 // route represents a single route configuration
 type route struct {
@@ -78,6 +81,64 @@ func (b *routeBuilder) QueryParam(param, value string) *routeBuilder {
 	return b
 }
 
+// Cookie adds in the current route a cookie name and value to match against
+func (b *routeBuilder) Cookie(cookie, value string) *routeBuilder {
+	if b.curr.options.Cookies == nil {
+		b.curr.options.Cookies = make(map[string]string)
+	}
+
+	b.curr.options.Cookies[cookie] = value
+	return b
+}
+
+// RemoteCIDRs sets the list of IPs or CIDRs the client IP must belong to
+func (b *routeBuilder) RemoteCIDRs(cidrs ...string) *routeBuilder {
+	b.curr.options.RemoteCIDRs = cidrs
+	return b
+}
+
+// Ports sets the list of ports to match against
+func (b *routeBuilder) Ports(ports ...int) *routeBuilder {
+	b.curr.options.Ports = ports
+	return b
+}
+
+// Consumes sets the list of media types the request Content-Type must match
+func (b *routeBuilder) Consumes(mediaTypes ...string) *routeBuilder {
+	b.curr.options.Consumes = mediaTypes
+	return b
+}
+
+// Produces sets the list of media types the request Accept header must accept
+func (b *routeBuilder) Produces(mediaTypes ...string) *routeBuilder {
+	b.curr.options.Produces = mediaTypes
+	return b
+}
+
+// CORS sets the CORS policy of the current route
+func (b *routeBuilder) CORS(policy *CORSPolicy) *routeBuilder {
+	b.curr.options.CORS = policy
+	return b
+}
+
+// Timeout sets the deadline to run the handler of the current route with
+func (b *routeBuilder) Timeout(timeout time.Duration) *routeBuilder {
+	b.curr.options.Timeout = timeout
+	return b
+}
+
+// DisableETag opts the current route out of the ETag middleware
+func (b *routeBuilder) DisableETag() *routeBuilder {
+	b.curr.options.DisableETag = true
+	return b
+}
+
+// Middlewares sets the middlewares wrapping the handler of the current route
+func (b *routeBuilder) Middlewares(middlewares ...Middleware) *routeBuilder {
+	b.curr.options.Middlewares = middlewares
+	return b
+}
+
 // Matcher sets a CustomMatcher function to match the route
 func (b *routeBuilder) Matcher(f CustomMatcher) *routeBuilder {
 	b.curr.options.Custom = f
@@ -24,6 +24,11 @@ func TestRouteBuilder(t *testing.T) {
 	builder.QueryParam("p1", "v1")
 	builder.QueryParam("p2", "v2")
 	builder.Matcher(m)
+	builder.Cookie("c1", "v1")
+	builder.RemoteCIDRs("10.0.0.0/8")
+	builder.Ports(80, 8080)
+	builder.Consumes("application/json")
+	builder.Produces("application/xml", "text/xml")
 
 	expected := route{
 		method:  "GET",
@@ -42,6 +47,13 @@ func TestRouteBuilder(t *testing.T) {
 				"p2": "v2",
 			},
 			Custom: m,
+			Cookies: map[string]string{
+				"c1": "v1",
+			},
+			RemoteCIDRs: []string{"10.0.0.0/8"},
+			Ports:       []int{80, 8080},
+			Consumes:    []string{"application/json"},
+			Produces:    []string{"application/xml", "text/xml"},
 		},
 	}
 
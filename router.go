@@ -3,82 +3,155 @@ package routing
 import (
 	"context"
 	"fmt"
+	"mime"
+	"net"
 	"net/http"
-	"reflect"
-	"runtime"
+	"os"
+	"path"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type paramsKey int
 
 var ctxKey paramsKey
 
-var handlers = make(map[string]http.HandlerFunc)
+// GetURLParameters is in charge of retrieve dynamic parameter of the URL within your route.
+// For example, User's ID in /users/{userId}
+func GetURLParameters(request *http.Request) URLParameterBag {
+	hostParams, _ := request.Context().Value(hostCtxKey).(URLParameterBag)
+
+	ctx := request.Context().Value(ctxKey)
+	if ctx == nil {
+		return hostParams
+	}
+
+	leaf := ctx.(*node)
 
-// AddHandler adds an http.HandlerFunc into a list of handlers to be retrieved
-// by name (canonical or alias) on runtime
-func AddHandler(handler http.HandlerFunc, aliases ...string) {
-	name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
-	handlers[strings.TrimRight(name, "-fm")] = handler
+	urlParams := buildURLParameters(leaf.position(), request.URL.Path, len(request.URL.Path), 0)
 
-	for _, alias := range aliases {
-		handlers[alias] = handler
+	routeParams := newURLParameterBag(0)
+	for _, matcher := range leaf.matchers {
+		if matches, hostLeaf := matcher(request); matches {
+			routeParams = routeParams.merge(buildURLParameters(hostLeaf, request.Host, len(request.Host), 0))
+		}
 	}
-}
 
-// GetHandler retrieves an http.HandlerFunc given a name from the list of handlers
-func GetHandler(name string) (http.HandlerFunc, error) {
-	handler, ok := handlers[name]
-	if !ok {
-		return nil, fmt.Errorf("handler with name %s not registered", name)
+	for _, extract := range leaf.extractors {
+		routeParams = routeParams.merge(extract(request))
 	}
 
-	return handler, nil
+	// the parameters captured by the route win over the ones of the HostRouter
+	urlParams = urlParams.merge(hostParams.without(urlParams.merge(routeParams)))
+
+	return urlParams.merge(routeParams)
 }
 
-var matchers = make(map[string]CustomMatcher)
+// RouteName returns the name of the route matched by the request, or an empty
+// string if the request was not dispatched by a Router
+func RouteName(request *http.Request) string {
+	leaf := matchedRoute(request)
+	if leaf == nil {
+		return ""
+	}
 
-// AddCustomMatcher adds a customer route matcher into a list of matchers to be
-// retrieved by name (canonical or alias) on runtime
-func AddCustomMatcher(m CustomMatcher, aliases ...string) {
-	name := runtime.FuncForPC(reflect.ValueOf(m).Pointer()).Name()
-	matchers[strings.TrimRight(name, "-fm")] = m
+	return leaf.name
+}
 
-	for _, alias := range aliases {
-		matchers[alias] = m
+// RouteTemplate returns the path template of the route matched by the
+// request, like /users/{id}, or an empty string if the request was not
+// dispatched by a Router
+func RouteTemplate(request *http.Request) string {
+	leaf := matchedRoute(request)
+	if leaf == nil {
+		return ""
 	}
+
+	return leaf.template()
 }
 
-// GetCustomMatcher retrieves a custom matcher given a name from the list of matchers
-func GetCustomMatcher(name string) (CustomMatcher, error) {
-	m, ok := matchers[name]
-	if !ok {
-		return nil, fmt.Errorf("custom matcher with name %s not registered", name)
-	}
+type requestSlotKey int
+
+var requestSlotCtxKey requestSlotKey
 
-	return m, nil
+// requestSlot holds what the inner handlers of a request learn about it, like
+// the route matched by a Router or the request ID. It lets the middlewares
+// wrapping them, like Logger or Recover, know about it too. Its access is
+// synchronized as the inner handlers may run in another goroutine, like the
+// ones of Timeout do.
+type requestSlot struct {
+	mu        sync.Mutex
+	leaf      *node
+	requestID string
+	stack     []byte
 }
 
-// GetURLParameters is in charge of retrieve dynamic parameter of the URL within your route.
-// For example, User's ID in /users/{userId}
-func GetURLParameters(request *http.Request) URLParameterBag {
-	ctx := request.Context().Value(ctxKey)
-	if ctx == nil {
-		return newURLParameterBag(0)
+func (s *requestSlot) setLeaf(leaf *node) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leaf = leaf
+}
+
+func (s *requestSlot) getLeaf() *node {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leaf
+}
+
+func (s *requestSlot) setRequestID(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requestID = id
+}
+
+func (s *requestSlot) getRequestID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requestID
+}
+
+// setPanicStack keeps the stack trace of a panic recovered in another
+// goroutine and raised again, which would lose it
+func (s *requestSlot) setPanicStack(stack []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stack = stack
+}
+
+func (s *requestSlot) getPanicStack() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stack
+}
+
+func withRequestSlot(request *http.Request) (*http.Request, *requestSlot) {
+	if slot := getRequestSlot(request); slot != nil {
+		return request, slot
 	}
 
-	leaf := ctx.(*node)
+	slot := &requestSlot{}
+	return request.WithContext(context.WithValue(request.Context(), requestSlotCtxKey, slot)), slot
+}
 
-	urlParams := buildURLParameters(leaf, request.URL.Path, len(request.URL.Path), 0)
+func getRequestSlot(request *http.Request) *requestSlot {
+	slot, _ := request.Context().Value(requestSlotCtxKey).(*requestSlot)
+	return slot
+}
 
-	for _, matcher := range leaf.matchers {
-		if matches, hostLeaf := matcher(request); matches {
-			urlParams = urlParams.merge(buildURLParameters(hostLeaf, request.Host, len(request.Host), 0))
-		}
+// matchedRoute returns the route matched by the request, either by the Router
+// serving it or by the Routers served after a withRequestSlot call
+func matchedRoute(request *http.Request) *node {
+	if leaf, ok := request.Context().Value(ctxKey).(*node); ok {
+		return leaf
 	}
 
-	return urlParams
+	if slot := getRequestSlot(request); slot != nil {
+		return slot.getLeaf()
+	}
+	return nil
 }
 
 func buildURLParameters(leaf *node, path string, offset int, paramsCount uint) URLParameterBag {
@@ -105,14 +178,41 @@ type RouterConfig struct {
 	EnableAutoMethodHead           bool
 	EnableAutoMethodOptions        bool
 	EnableMethodNotAllowedResponse bool
+	// Registry to retrieve handlers, matchers and middlewares from when
+	// loading routes, the default one is used when nil
+	Registry *Registry
+	// TrustedProxies is a list of IPs or CIDRs of the proxies whose forwarding
+	// headers are trusted to resolve the client IP and the request scheme
+	TrustedProxies []string
+	// ForwardingHeader selects the headers of the trusted proxies to resolve
+	// the client IP and the request scheme with, X-Forwarded-For and
+	// X-Forwarded-Proto by default
+	ForwardingHeader ForwardingHeader
+	// ForceHTTPS redirects plain http requests to https when the request would
+	// only match a route restricted to the https scheme
+	ForceHTTPS bool
+	// CORS is the policy of the routes registered without one of their own
+	CORS *CORSPolicy
+	// Middlewares wrap the handlers of all the routes of the router, including
+	// the ones of the routers added with Prefix, for instance to require
+	// authentication to a group of routes
+	Middlewares []Middleware
+	// ErrorHandler responds the errors returned by the HandlerE and MiddlewareE
+	// of the routes registered without one, DefaultErrorHandler when nil
+	ErrorHandler ErrorHandler
+	// Hooks are called while serving the requests, to observe the route
+	// lookups and their outcome
+	Hooks RouterHooks
 }
 
 // Router is a structure where all routes are stored
 type Router struct {
-	config RouterConfig
-	trees  map[string]*tree
-	asName string
-	routes map[string]*node
+	config     RouterConfig
+	trees      map[string]*tree
+	asName     string
+	routes     map[string]*node
+	negotiates bool
+	resolver   *ClientIPResolver
 }
 
 // NewRouter returns an empty Router
@@ -121,46 +221,162 @@ func NewRouter(configs ...RouterConfig) Router {
 	if len(configs) > 0 {
 		defaultConfig = configs[0]
 	}
-	return Router{config: defaultConfig}
+	// built eagerly so that serving requests only reads it, a malformed
+	// trusted proxy is reported when registering the routes depending on it
+	resolver, _ := NewClientIPResolverWithHeader(defaultConfig.ForwardingHeader, defaultConfig.TrustedProxies...)
+
+	return Router{config: defaultConfig, resolver: resolver}
 }
 
 // ServerHTTP executes the HandlerFunc if the request path is found
 func (r *Router) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	if isPreflight(request) && r.preflight(response, request) {
+		return
+	}
+
 	tree, ok := r.trees[request.Method]
 	if !ok {
 		r.notFoundOrMethodNotAllowed(response, request)
 		return
 	}
 
-	leaf := tree.find(request)
+	leaf := r.config.Hooks.find(tree, request)
 	if leaf == nil {
 		r.notFoundOrMethodNotAllowed(response, request)
 		return
 	}
 
-	if leaf.hasParameters() {
-		ctx := context.Background()
-		request = request.WithContext(context.WithValue(ctx, ctxKey, leaf))
+	if slot := getRequestSlot(request); slot != nil {
+		slot.setLeaf(leaf)
+	}
+
+	if leaf.cors != nil {
+		leaf.cors.decorate(response, request)
 	}
+
+	request = request.WithContext(context.WithValue(request.Context(), ctxKey, leaf))
+	r.config.Hooks.match(request)
 	leaf.handler(response, request)
 }
 
+// preflight answers a CORS preflight request if the route of the requested
+// method has a CORS policy
+func (r *Router) preflight(response http.ResponseWriter, request *http.Request) bool {
+	method := request.Header.Get("Access-Control-Request-Method")
+	tree, ok := r.trees[method]
+	if !ok {
+		return false
+	}
+
+	actual := withNegotiationSkipped(request, skipProduces|skipConsumes)
+	actual.Method = method
+
+	leaf := tree.find(actual)
+	if leaf == nil || leaf.cors == nil {
+		return false
+	}
+
+	leaf.cors.preflight(response, request, getAvailableMethods(r, actual))
+	return true
+}
+
 func (r *Router) notFoundOrMethodNotAllowed(response http.ResponseWriter, request *http.Request) {
+	if r.config.ForceHTTPS && r.redirectToHTTPS(response, request) {
+		return
+	}
+
+	if code := r.negotiationFailure(request); code != 0 {
+		http.Error(response, strconv.Itoa(code)+" "+strings.ToLower(http.StatusText(code)), code)
+		return
+	}
+
 	if !r.config.EnableMethodNotAllowedResponse {
+		r.config.Hooks.notFound(request)
 		http.NotFound(response, request)
 		return
 	}
 
 	availVerbs := getAvailableMethods(r, request)
 	if len(availVerbs) == 0 {
+		r.config.Hooks.notFound(request)
 		http.NotFound(response, request)
 		return
 	}
 
+	r.config.Hooks.methodNotAllowed(request, availVerbs)
 	response.Header().Set("Allow", strings.Join(availVerbs, ", "))
 	http.Error(response, "405 method not allowed", http.StatusMethodNotAllowed)
 }
 
+// redirectToHTTPS redirects plain http requests to https if the request would
+// match a route using the https scheme
+func (r *Router) redirectToHTTPS(response http.ResponseWriter, request *http.Request) bool {
+	resolver, err := r.clientIPResolver()
+	if err != nil || requestScheme(request, resolver) != "http" {
+		return false
+	}
+
+	tree, ok := r.trees[request.Method]
+	if !ok {
+		return false
+	}
+
+	httpsRequest := request.WithContext(context.WithValue(request.Context(), forcedSchemeCtxKey, "https"))
+	if tree.find(httpsRequest) == nil {
+		return false
+	}
+
+	host := request.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	code := http.StatusMovedPermanently
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		code = http.StatusPermanentRedirect
+	}
+
+	location := "https://" + host + request.URL.RequestURI()
+	r.config.Hooks.redirect(request, location, code)
+	http.Redirect(response, request, location, code)
+	return true
+}
+
+// clientIPResolver returns the resolver of client IPs and schemes trusting the
+// proxies of the router configuration
+func (r *Router) clientIPResolver() (*ClientIPResolver, error) {
+	if r.resolver != nil {
+		return r.resolver, nil
+	}
+
+	resolver, err := NewClientIPResolverWithHeader(r.config.ForwardingHeader, r.config.TrustedProxies...)
+	if err != nil {
+		return nil, err
+	}
+	r.resolver = resolver
+
+	return resolver, nil
+}
+
+// negotiationFailure returns 406 or 415 status codes if the request path would
+// match a route but its content negotiation fails, or 0 otherwise
+func (r *Router) negotiationFailure(request *http.Request) int {
+	if !r.negotiates {
+		return 0
+	}
+
+	tree, ok := r.trees[request.Method]
+	if !ok || tree.find(withNegotiationSkipped(request, skipProduces|skipConsumes)) == nil {
+		return 0
+	}
+
+	if tree.find(withNegotiationSkipped(request, skipProduces)) != nil {
+		return http.StatusNotAcceptable
+	}
+
+	return http.StatusUnsupportedMediaType
+}
+
 // As method sets a name for the next registered route.
 //
 // Deprecated: MatchingOptions should be used instead and will have preference
@@ -172,12 +388,42 @@ func (r *Router) As(asName string) *Router {
 
 // MatchingOptions is a structure to define a route name and extend the matching options
 type MatchingOptions struct {
-	Name        string
-	Host        string
-	Schemas     []string
+	Name string
+	// Host accepts the {name:regexp} syntax of paths, a leading *. matching any
+	// single label and an optional port, the request port is ignored otherwise
+	Host    string
+	Schemas []string
+	// Headers and QueryParams values accept the {name:regexp} syntax of paths,
+	// captured values are available through GetURLParameters. An empty value
+	// just requires the header or query parameter to be present.
 	Headers     map[string]string
 	QueryParams map[string]string
-	Custom      CustomMatcher
+	// Custom matches requests with a function. Routes with the same path and
+	// matching options override each other, but the ones with Custom do not,
+	// as functions can not be compared.
+	Custom CustomMatcher
+	// Cookies values accept the same syntax of Headers and QueryParams
+	Cookies map[string]string
+	// RemoteCIDRs restricts the client IP, resolved taking into account the
+	// RouterConfig.TrustedProxies, to any of the given IPs or CIDRs
+	RemoteCIDRs []string
+	Ports       []int
+	// Consumes and Produces restrict the request Content-Type and Accept
+	// headers to any of the given media types. Among routes with the same
+	// path, the one producing the media type preferred by the request wins.
+	Consumes []string
+	Produces []string
+	// CORS overrides the CORS policy of the router for the route
+	CORS *CORSPolicy
+	// Timeout runs the route handler with a deadline, overriding the one of
+	// the Timeout middleware if used
+	Timeout time.Duration
+	// Middlewares wrap the route handler, inside the ones of the router, for
+	// instance to require authentication with BasicAuth or BearerAuth
+	Middlewares []Middleware
+	// DisableETag sends the responses of the route as they are when using the
+	// ETag middleware, for instance for streamed or personalized responses
+	DisableETag bool
 }
 
 // NewMatchingOptions returns the MatchingOptions structure
@@ -189,11 +435,64 @@ func NewMatchingOptions() MatchingOptions {
 		Headers:     map[string]string{},
 		QueryParams: map[string]string{},
 		Custom:      nil,
+		Cookies:     map[string]string{},
+		RemoteCIDRs: nil,
+		Ports:       nil,
+	}
+}
+
+// matching returns a description of the options matching requests, the same
+// for options matching the same requests regardless of their order or case
+func (o MatchingOptions) matching() string {
+	var parts []string
+	add := func(kind string, values ...string) {
+		if len(values) == 0 {
+			return
+		}
+		values = append([]string(nil), values...)
+		sort.Strings(values)
+		parts = append(parts, kind+"="+strings.Join(values, ","))
+	}
+	pairs := func(m map[string]string, canonical func(string) string) []string {
+		var values []string
+		for k, v := range m {
+			values = append(values, canonical(k)+":"+v)
+		}
+		return values
+	}
+	unchanged := func(s string) string { return s }
+
+	if o.Host != "" {
+		add("host", strings.ToLower(o.Host))
+	}
+	schemas := make([]string, len(o.Schemas))
+	for i, schema := range o.Schemas {
+		schemas[i] = strings.ToLower(schema)
 	}
+	add("schemas", schemas...)
+	add("headers", pairs(o.Headers, http.CanonicalHeaderKey)...)
+	add("query", pairs(o.QueryParams, unchanged)...)
+	add("cookies", pairs(o.Cookies, unchanged)...)
+	add("cidrs", o.RemoteCIDRs...)
+	ports := make([]string, len(o.Ports))
+	for i, port := range o.Ports {
+		ports[i] = strconv.Itoa(port)
+	}
+	add("ports", ports...)
+	add("consumes", o.Consumes...)
+	add("produces", o.Produces...)
+
+	return strings.Join(parts, "\n")
 }
 
 // Register adds a new route in the router
 func (r *Router) Register(verb, path string, handler http.HandlerFunc, options ...MatchingOptions) error {
+	return r.register(verb, path, handler, false, options...)
+}
+
+// register adds a new route in the router, automatic routes are answered by
+// the router itself so no middleware wraps them
+func (r *Router) register(verb, path string, handler http.HandlerFunc, auto bool, options ...MatchingOptions) error {
 	if len(verb) < 3 {
 		return fmt.Errorf("invalid verb %s", verb)
 	}
@@ -220,7 +519,38 @@ func (r *Router) Register(verb, path string, handler http.HandlerFunc, options .
 		r.trees[verb] = &tree{}
 	}
 
-	leaf := r.trees[verb].insert(parser.chunks, handler)
+	// the route timeout runs inside the middlewares, like it does inside the
+	// ones of the routers the router is added to with Prefix
+	routeHandler := handler
+	if len(options) > 0 && options[0].Timeout > 0 {
+		routeHandler = timeoutHandler(options[0].Timeout, handler)
+	}
+
+	if !auto && (len(r.config.Middlewares) > 0 || (len(options) > 0 && len(options[0].Middlewares) > 0)) {
+		pipe := NewMiddlewarePipe().Next(r.config.Middlewares...)
+		if len(options) > 0 {
+			pipe.Next(options[0].Middlewares...)
+		}
+		routeHandler = pipe.Then(routeHandler)
+	}
+
+	route := &node{routeAttributes: routeAttributes{handler: routeHandler, errorHandler: r.config.ErrorHandler, auto: auto}}
+	if len(options) > 0 {
+		route.timeout = options[0].Timeout
+	}
+
+	if len(options) > 0 {
+		route.noETag = options[0].DisableETag
+	}
+
+	policy := r.config.CORS
+	if len(options) > 0 && options[0].CORS != nil {
+		policy = options[0].CORS
+	}
+	route.cors, err = newCORSPolicy(policy)
+	if err != nil {
+		return err
+	}
 
 	rname := r.asName
 	r.asName = ""
@@ -233,43 +563,112 @@ func (r *Router) Register(verb, path string, handler http.HandlerFunc, options .
 			if err != nil {
 				return err
 			}
-			leaf.matchers = append(leaf.matchers, matcherByHost)
+			route.matchers = append(route.matchers, matcherByHost.match)
+			if matcherByHost.hasParameters() {
+				route.extractors = append(route.extractors, matcherByHost.parameters)
+			}
 		}
 
 		if len(options[0].Schemas) > 0 {
-			matcherBySchemas, err := bySchemas(options[0].Schemas...)
+			resolver, err := r.clientIPResolver()
+			if err != nil {
+				return err
+			}
+			matcherBySchemas, err := bySchemas(resolver, options[0].Schemas...)
 			if err != nil {
 				return err
 			}
-			leaf.matchers = append(leaf.matchers, matcherBySchemas)
+			route.matchers = append(route.matchers, matcherBySchemas)
 		}
 
 		if len(options[0].Headers) > 0 {
-			matcherByHeaders := byHeaders(options[0].Headers)
-			leaf.matchers = append(leaf.matchers, matcherByHeaders)
+			matcherByHeaders, err := byHeaders(options[0].Headers)
+			if err != nil {
+				return err
+			}
+			route.matchers = append(route.matchers, matcherByHeaders.match)
+			if matcherByHeaders.hasParameters() {
+				route.extractors = append(route.extractors, matcherByHeaders.parameters)
+			}
 		}
 
 		if len(options[0].QueryParams) > 0 {
-			matcherByQueryParams := byQueryParameters(options[0].QueryParams)
-			leaf.matchers = append(leaf.matchers, matcherByQueryParams)
+			matcherByQueryParams, err := byQueryParameters(options[0].QueryParams)
+			if err != nil {
+				return err
+			}
+			route.matchers = append(route.matchers, matcherByQueryParams.match)
+			if matcherByQueryParams.hasParameters() {
+				route.extractors = append(route.extractors, matcherByQueryParams.parameters)
+			}
+		}
+
+		if len(options[0].Cookies) > 0 {
+			matcherByCookies, err := byCookies(options[0].Cookies)
+			if err != nil {
+				return err
+			}
+			route.matchers = append(route.matchers, matcherByCookies.match)
+			if matcherByCookies.hasParameters() {
+				route.extractors = append(route.extractors, matcherByCookies.parameters)
+			}
+		}
+
+		if len(options[0].RemoteCIDRs) > 0 {
+			resolver, err := r.clientIPResolver()
+			if err != nil {
+				return err
+			}
+			matcherByRemoteCIDRs, err := byRemoteCIDRs(resolver, options[0].RemoteCIDRs...)
+			if err != nil {
+				return err
+			}
+			route.matchers = append(route.matchers, matcherByRemoteCIDRs)
+		}
+
+		if len(options[0].Ports) > 0 {
+			matcherByPorts := byPorts(options[0].Ports...)
+			route.matchers = append(route.matchers, matcherByPorts)
+		}
+
+		if len(options[0].Consumes) > 0 {
+			consumes, err := parseMediaRanges(options[0].Consumes...)
+			if err != nil {
+				return err
+			}
+			route.matchers = append(route.matchers, byConsumes(consumes))
+			r.negotiates = true
+		}
+
+		if len(options[0].Produces) > 0 {
+			produces, err := parseMediaRanges(options[0].Produces...)
+			if err != nil {
+				return err
+			}
+			route.matchers = append(route.matchers, byProduces(produces))
+			route.produces = produces
+			r.negotiates = true
 		}
 
 		if options[0].Custom != nil {
 			matcherByCustomFunc := byCustomMatcher(options[0].Custom)
-			leaf.matchers = append(leaf.matchers, matcherByCustomFunc)
+			route.matchers = append(route.matchers, matcherByCustomFunc)
 		}
+
+		route.matching = options[0].matching()
+		route.custom = options[0].Custom != nil
 	}
 
-	rname = r.generateRouteName(rname, parser)
+	route.name = r.generateRouteName(rname, parser)
 
-	r.routes[rname] = leaf
+	r.routes[route.name] = r.trees[verb].insertRoute(parser.chunks, route)
 
 	if r.config.EnableAutoMethodHead && verb == http.MethodGet {
 		_ = r.Register(http.MethodHead, path, handler, options...)
 	}
 
 	if r.config.EnableAutoMethodOptions && verb != http.MethodOptions {
-		_ = r.Register(http.MethodOptions, path, getAutoMethodOptionsHandler(r), options...)
+		_ = r.register(http.MethodOptions, path, getAutoMethodOptionsHandler(r), true, options...)
 	}
 
 	return nil
@@ -369,7 +768,74 @@ func (r *Router) Any(path string, handler http.HandlerFunc, options ...MatchingO
 	return nil
 }
 
-// Prefix combines two routers under a custom path prefix
+// RegisterE registers a new route in the router with a handler returning
+// errors, responded by the RouterConfig.ErrorHandler
+func (r *Router) RegisterE(verb, path string, handler HandlerE, options ...MatchingOptions) error {
+	return r.Register(verb, path, handler.ServeHTTP, options...)
+}
+
+// HeadE is a method to register a new HEAD route in the router with a handler
+// returning errors.
+func (r *Router) HeadE(path string, handler HandlerE, options ...MatchingOptions) error {
+	return r.RegisterE(http.MethodHead, path, handler, options...)
+}
+
+// GetE is a method to register a new GET route in the router with a handler
+// returning errors.
+func (r *Router) GetE(path string, handler HandlerE, options ...MatchingOptions) error {
+	return r.RegisterE(http.MethodGet, path, handler, options...)
+}
+
+// PostE is a method to register a new POST route in the router with a handler
+// returning errors.
+func (r *Router) PostE(path string, handler HandlerE, options ...MatchingOptions) error {
+	return r.RegisterE(http.MethodPost, path, handler, options...)
+}
+
+// PutE is a method to register a new PUT route in the router with a handler
+// returning errors.
+func (r *Router) PutE(path string, handler HandlerE, options ...MatchingOptions) error {
+	return r.RegisterE(http.MethodPut, path, handler, options...)
+}
+
+// PatchE is a method to register a new PATCH route in the router with a handler
+// returning errors.
+func (r *Router) PatchE(path string, handler HandlerE, options ...MatchingOptions) error {
+	return r.RegisterE(http.MethodPatch, path, handler, options...)
+}
+
+// DeleteE is a method to register a new DELETE route in the router with a handler
+// returning errors.
+func (r *Router) DeleteE(path string, handler HandlerE, options ...MatchingOptions) error {
+	return r.RegisterE(http.MethodDelete, path, handler, options...)
+}
+
+// ConnectE is a method to register a new CONNECT route in the router with a handler
+// returning errors.
+func (r *Router) ConnectE(path string, handler HandlerE, options ...MatchingOptions) error {
+	return r.RegisterE(http.MethodConnect, path, handler, options...)
+}
+
+// OptionsE is a method to register a new OPTIONS route in the router with a handler
+// returning errors.
+func (r *Router) OptionsE(path string, handler HandlerE, options ...MatchingOptions) error {
+	return r.RegisterE(http.MethodOptions, path, handler, options...)
+}
+
+// TraceE is a method to register a new TRACE route in the router with a handler
+// returning errors.
+func (r *Router) TraceE(path string, handler HandlerE, options ...MatchingOptions) error {
+	return r.RegisterE(http.MethodTrace, path, handler, options...)
+}
+
+// AnyE is a method to register a new route with all the verbs with a handler
+// returning errors.
+func (r *Router) AnyE(path string, handler HandlerE, options ...MatchingOptions) error {
+	return r.Any(path, handler.ServeHTTP, options...)
+}
+
+// Prefix combines two routers under a custom path prefix. The routes of the
+// given router trust the proxies of this one.
 func (r *Router) Prefix(path string, router *Router) error {
 	parser := newParser(path)
 	_, err := parser.parse()
@@ -381,11 +847,40 @@ func (r *Router) Prefix(path string, router *Router) error {
 		r.trees = make(map[string]*tree)
 	}
 
+	if nil == r.routes {
+		r.routes = make(map[string]*node)
+	}
+
+	policy, err := newCORSPolicy(r.config.CORS)
+	if err != nil {
+		return err
+	}
+
+	if router.resolver != nil {
+		resolver, err := r.clientIPResolver()
+		if err != nil {
+			return err
+		}
+		router.resolver.bound = resolver
+	}
+
 	for verb, t := range router.trees {
 		if _, ok := r.trees[verb]; !ok {
 			r.trees[verb] = &tree{}
 		}
 
+		for _, route := range t.routes() {
+			if route.cors == nil {
+				route.cors = policy
+			}
+			if route.errorHandler == nil {
+				route.errorHandler = r.config.ErrorHandler
+			}
+			if len(r.config.Middlewares) > 0 && !route.auto {
+				route.handler = NewMiddlewarePipe().Next(r.config.Middlewares...).Then(route.handler)
+			}
+		}
+
 		rootNew, leafNew := createTreeFromChunks(parser.chunks)
 		t.root.parent = leafNew
 
@@ -399,29 +894,118 @@ func (r *Router) Prefix(path string, router *Router) error {
 	}
 
 	r.asName = ""
+	r.negotiates = r.negotiates || router.negotiates
 
 	for name, leaf := range router.routes {
-		r.routes[r.generateRouteName(name, nil)] = leaf
+		leaf.name = r.generateRouteName(name, nil)
+		r.routes[leaf.name] = leaf
 	}
 
 	return nil
 }
 
+// StaticFilesOptions configures how StaticFiles serves the files
+type StaticFilesOptions struct {
+	// Precompressed is the list of encodings, in order of preference, whose
+	// precompressed siblings like app.js.br or app.js.gz are served instead of
+	// the files when the request accepts them
+	Precompressed []string
+}
+
+var precompressedExtensions = map[string]string{
+	"br":   ".br",
+	"gzip": ".gz",
+	"zstd": ".zst",
+}
+
 // StaticFiles  will serve files from a directory under a prefix path
-func (r *Router) StaticFiles(prefix, dir string) error {
+func (r *Router) StaticFiles(prefix, dir string, options ...StaticFilesOptions) error {
+	var precompressed []string
+	if len(options) > 0 {
+		precompressed = options[0].Precompressed
+	}
+
 	return r.Register("GET", prefix+"/{name:.*}", func(writer http.ResponseWriter, request *http.Request) {
 
 		urlParams := GetURLParameters(request)
 		name, _ := urlParams.GetByName("name")
 
+		if len(precompressed) > 0 && servePrecompressed(writer, request, http.Dir(dir), name, precompressed) {
+			return
+		}
+
 		request.URL.Path = name
 		http.FileServer(http.Dir(dir)).ServeHTTP(writer, request)
 	})
 }
 
+// servePrecompressed serves the precompressed sibling of a file with the
+// encoding preferred by the request among the existing ones, if any
+func servePrecompressed(w http.ResponseWriter, r *http.Request, fs http.FileSystem, name string, encodings []string) bool {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		return false
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	candidates := append([]string{}, encodings...)
+	for {
+		encoding := negotiateEncoding(r, candidates)
+		if encoding == "" {
+			return false
+		}
+
+		if f, info := openPrecompressed(fs, name, encoding); f != nil {
+			defer f.Close()
+
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Encoding", encoding)
+			http.ServeContent(w, r, name, info.ModTime(), f)
+			return true
+		}
+
+		for i, c := range candidates {
+			if c == encoding {
+				candidates = append(candidates[:i], candidates[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func openPrecompressed(fs http.FileSystem, name, encoding string) (http.File, os.FileInfo) {
+	ext, ok := precompressedExtensions[encoding]
+	if !ok {
+		ext = "." + encoding
+	}
+
+	f, err := fs.Open(path.Clean("/"+name) + ext)
+	if err != nil {
+		return nil, nil
+	}
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		_ = f.Close()
+		return nil, nil
+	}
+
+	return f, info
+}
+
 // Redirect will redirect a path to an url
 func (r *Router) Redirect(path, url string, code ...int) error {
-	return r.Register(http.MethodGet, path, getRedirectHandler(url, code...))
+	redirect := getRedirectHandler(url, code...)
+	if r.config.Hooks.OnRedirect == nil {
+		return r.Register(http.MethodGet, path, redirect)
+	}
+
+	status := redirectCode(code...)
+	return r.Register(http.MethodGet, path, func(w http.ResponseWriter, req *http.Request) {
+		r.config.Hooks.redirect(req, url, status)
+		redirect(w, req)
+	})
 }
 
 // GenerateURL generates a URL from route name
@@ -432,7 +1016,7 @@ func (r *Router) GenerateURL(name string, params URLParameterBag) (string, error
 	}
 
 	var url strings.Builder
-	err := getUri(node, &url, params)
+	err := getUri(node.position(), &url, params)
 	if err != nil {
 		return "", err
 	}
@@ -483,6 +1067,12 @@ type RouteDefOptions struct {
 	Headers       map[string]string
 	QueryParams   map[string]string
 	CustomMatcher string
+	Middlewares   []string
+	Cookies       map[string]string
+	RemoteCIDRs   []string
+	Ports         []int
+	Consumes      []string
+	Produces      []string
 }
 
 // Loader loads a list routes
@@ -490,36 +1080,67 @@ type Loader interface {
 	Load() []RouteDef
 }
 
-// Load registers a list of routes retrieved from a loader
-func (r *Router) Load(loader Loader) error {
+// Load registers a list of routes retrieved from a loader. Handlers, custom
+// matchers and middlewares are retrieved by name from the given registry, or
+// from the one of the router configuration or the default one otherwise.
+func (r *Router) Load(loader Loader, registry ...*Registry) error {
+	reg := r.config.Registry
+	if len(registry) > 0 && registry[0] != nil {
+		reg = registry[0]
+	}
+	if reg == nil {
+		reg = defaultRegistry
+	}
+
 	for _, route := range loader.Load() {
-		handler, err := GetHandler(route.Handler)
-		if err != nil {
-			return err
+		if err := r.loadRoute(route, reg); err != nil {
+			return fmt.Errorf("route %s %s: %w", route.Method, route.Path, err)
 		}
+	}
+	return nil
+}
+
+func (r *Router) loadRoute(route RouteDef, reg *Registry) error {
+	handler, err := reg.GetHandler(route.Handler)
+	if err != nil {
+		return err
+	}
 
-		var matcher CustomMatcher
-		if len(route.Options.CustomMatcher) > 0 {
-			matcher, err = GetCustomMatcher(route.Options.CustomMatcher)
+	if len(route.Options.Middlewares) > 0 {
+		pipe := NewMiddlewarePipe()
+		for _, name := range route.Options.Middlewares {
+			m, err := reg.GetMiddleware(name)
 			if err != nil {
 				return err
 			}
+			pipe.Next(m)
 		}
+		handler = pipe.Then(handler)
+	}
 
-		options := MatchingOptions{
-			Name:        route.Options.Name,
-			Host:        route.Options.Host,
-			Schemas:     route.Options.Schemas,
-			Headers:     route.Options.Headers,
-			QueryParams: route.Options.QueryParams,
-			Custom:      matcher,
-		}
-		err = r.Register(route.Method, route.Path, handler, options)
+	var matcher CustomMatcher
+	if len(route.Options.CustomMatcher) > 0 {
+		matcher, err = reg.GetCustomMatcher(route.Options.CustomMatcher)
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+
+	options := MatchingOptions{
+		Name:        route.Options.Name,
+		Host:        route.Options.Host,
+		Schemas:     route.Options.Schemas,
+		Headers:     route.Options.Headers,
+		QueryParams: route.Options.QueryParams,
+		Custom:      matcher,
+		Cookies:     route.Options.Cookies,
+		RemoteCIDRs: route.Options.RemoteCIDRs,
+		Ports:       route.Options.Ports,
+		Consumes:    route.Options.Consumes,
+		Produces:    route.Options.Produces,
+	}
+
+	return r.Register(route.Method, route.Path, handler, options)
 }
 
 // PrioritizeByWeight changes the router underlying tree to prioritize search
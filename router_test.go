@@ -1,6 +1,7 @@
 package routing
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -198,6 +199,49 @@ func TestGetURLParameters_ContainsHostParameters(t *testing.T) {
 	assertPathWithHostFound(t, mainRouter, "GET", "/path1/100", "dummy.test.com")
 }
 
+func TestGetURLParameters_ContainsHeaderAndQueryParameters(t *testing.T) {
+	mainRouter := Router{}
+
+	bag := newURLParameterBag(3)
+	bag.add("id", "100")
+	bag.add("version", "2")
+	bag.add("page", "3")
+
+	f := assertRequestHasParameterHandler(t, bag)
+	options := NewMatchingOptions()
+	options.Headers["Accept"] = "application/vnd.acme.v{version:[0-9]+}+json"
+	options.QueryParams["page"] = "{page:[0-9]+}"
+
+	_ = mainRouter.Register(http.MethodGet, "/path1/{id}", f, options)
+
+	r, _ := http.NewRequest(http.MethodGet, "/path1/100?page=3", nil)
+	r.Header.Set("Accept", "application/vnd.acme.v2+json")
+	w := httptest.NewRecorder()
+	mainRouter.ServeHTTP(w, r)
+	assertEqual(t, http.StatusOK, w.Code)
+
+	r, _ = http.NewRequest(http.MethodGet, "/path1/100?page=3", nil)
+	r.Header.Set("Accept", "application/json")
+	w = httptest.NewRecorder()
+	mainRouter.ServeHTTP(w, r)
+	assertEqual(t, http.StatusNotFound, w.Code)
+}
+
+func TestRouter_MatchingOptions_MatchesByHeadersReturnsErrorWhenMalformedValue(t *testing.T) {
+	mainRouter := Router{}
+	options := NewMatchingOptions()
+	options.Headers["Accept"] = "v{version"
+
+	err := mainRouter.Get("/path1", testHandlerFunc, options)
+	assertNotNil(t, err)
+
+	options = NewMatchingOptions()
+	options.QueryParams["page"] = "{page:[0-9}"
+
+	err = mainRouter.Get("/path1", testHandlerFunc, options)
+	assertNotNil(t, err)
+}
+
 func TestRouter_AllVerbs(t *testing.T) {
 	path := "/path1"
 
@@ -372,17 +416,17 @@ func TestRouter_Prefix_CreateTreeWhenStillNotCreated(t *testing.T) {
 func TestRouter_MatchingOptions_AssignsRouteNames(t *testing.T) {
 	mainRouter := Router{}
 
-	_ = mainRouter.Get("/users", testHandlerFunc, MatchingOptions{"users.get", "", []string{}, map[string]string{}, map[string]string{}, nil})
-	_ = mainRouter.Post("/users", testHandlerFunc, MatchingOptions{"users.create", "", []string{}, map[string]string{}, map[string]string{}, nil})
-	_ = mainRouter.Post("/users/create", testHandlerFunc, MatchingOptions{"users.create", "", []string{}, map[string]string{}, map[string]string{}, nil})
-	_ = mainRouter.Put("/users/{id}", testHandlerFunc, MatchingOptions{"users.update", "", []string{}, map[string]string{}, map[string]string{}, nil})
-	_ = mainRouter.Delete("/users/{id}", testDummyHandlerFunc, MatchingOptions{"users.delete", "", []string{}, map[string]string{}, map[string]string{}, nil})
-	_ = mainRouter.Delete("/users/{id}", testHandlerFunc, MatchingOptions{"users.softDelete", "", []string{}, map[string]string{}, map[string]string{}, nil})
+	_ = mainRouter.Get("/users", testHandlerFunc, MatchingOptions{Name: "users.get"})
+	_ = mainRouter.Post("/users", testHandlerFunc, MatchingOptions{Name: "users.create"})
+	_ = mainRouter.Post("/users/create", testHandlerFunc, MatchingOptions{Name: "users.create"})
+	_ = mainRouter.Put("/users/{id}", testHandlerFunc, MatchingOptions{Name: "users.update"})
+	_ = mainRouter.Delete("/users/{id}", testDummyHandlerFunc, MatchingOptions{Name: "users.delete"})
+	_ = mainRouter.Delete("/users/{id}", testHandlerFunc, MatchingOptions{Name: "users.softDelete"})
 	_ = mainRouter.Get("/users/profile", testDummyHandlerFunc)
 
 	apiRouter := Router{}
-	_ = apiRouter.Get("/users/account", testHandlerFunc, MatchingOptions{"users.account", "", []string{}, map[string]string{}, map[string]string{}, nil})
-	_ = apiRouter.Get("/users/profile", testHandlerFunc, MatchingOptions{"users.profile", "", []string{}, map[string]string{}, map[string]string{}, nil})
+	_ = apiRouter.Get("/users/account", testHandlerFunc, MatchingOptions{Name: "users.account"})
+	_ = apiRouter.Get("/users/profile", testHandlerFunc, MatchingOptions{Name: "users.profile"})
 
 	_ = mainRouter.Prefix("/api", &apiRouter)
 
@@ -399,17 +443,17 @@ func TestRouter_MatchingOptions_AssignsRouteNames(t *testing.T) {
 func TestRouter_MatchingOptions_AssignsRouteNamesOverAsMethod(t *testing.T) {
 	mainRouter := Router{}
 
-	_ = mainRouter.As("users.getAs").Get("/users", testHandlerFunc, MatchingOptions{"users.get", "", []string{}, map[string]string{}, map[string]string{}, nil})
-	_ = mainRouter.As("users.createAs").Post("/users", testHandlerFunc, MatchingOptions{"users.create", "", []string{}, map[string]string{}, map[string]string{}, nil})
-	_ = mainRouter.As("users.createAs").Post("/users/create", testHandlerFunc, MatchingOptions{"users.create", "", []string{}, map[string]string{}, map[string]string{}, nil})
-	_ = mainRouter.As("users.updateAs").Put("/users/{id}", testHandlerFunc, MatchingOptions{"users.update", "", []string{}, map[string]string{}, map[string]string{}, nil})
-	_ = mainRouter.As("users.deleteAs").Delete("/users/{id}", testDummyHandlerFunc, MatchingOptions{"users.delete", "", []string{}, map[string]string{}, map[string]string{}, nil})
-	_ = mainRouter.As("users.softDeleteAs").Delete("/users/{id}", testHandlerFunc, MatchingOptions{"users.softDelete", "", []string{}, map[string]string{}, map[string]string{}, nil})
+	_ = mainRouter.As("users.getAs").Get("/users", testHandlerFunc, MatchingOptions{Name: "users.get"})
+	_ = mainRouter.As("users.createAs").Post("/users", testHandlerFunc, MatchingOptions{Name: "users.create"})
+	_ = mainRouter.As("users.createAs").Post("/users/create", testHandlerFunc, MatchingOptions{Name: "users.create"})
+	_ = mainRouter.As("users.updateAs").Put("/users/{id}", testHandlerFunc, MatchingOptions{Name: "users.update"})
+	_ = mainRouter.As("users.deleteAs").Delete("/users/{id}", testDummyHandlerFunc, MatchingOptions{Name: "users.delete"})
+	_ = mainRouter.As("users.softDeleteAs").Delete("/users/{id}", testHandlerFunc, MatchingOptions{Name: "users.softDelete"})
 	_ = mainRouter.Get("/users/profile", testDummyHandlerFunc)
 
 	apiRouter := Router{}
-	_ = apiRouter.Get("/users/account", testHandlerFunc, MatchingOptions{"users.account", "", []string{}, map[string]string{}, map[string]string{}, nil})
-	_ = apiRouter.Get("/users/profile", testHandlerFunc, MatchingOptions{"users.profile", "", []string{}, map[string]string{}, map[string]string{}, nil})
+	_ = apiRouter.Get("/users/account", testHandlerFunc, MatchingOptions{Name: "users.account"})
+	_ = apiRouter.Get("/users/profile", testHandlerFunc, MatchingOptions{Name: "users.profile"})
 
 	_ = mainRouter.Prefix("/api", &apiRouter)
 
@@ -428,10 +472,10 @@ func TestRouter_MatchingOptions_MatchesByHost(t *testing.T) {
 
 	_ = mainRouter.Get("/users", testHandlerFunc, NewMatchingOptions())
 	_ = mainRouter.Get("/users/{id}", testHandlerFunc, NewMatchingOptions())
-	_ = mainRouter.Get("/users/{id}/create", testHandlerFunc, MatchingOptions{"", "test.com", []string{}, map[string]string{}, map[string]string{}, nil})
+	_ = mainRouter.Get("/users/{id}/create", testHandlerFunc, MatchingOptions{Host: "test.com"})
 
 	apiRouter := Router{}
-	_ = apiRouter.Get("/users/account", testHandlerFunc, MatchingOptions{"", "api.test.com", []string{}, map[string]string{}, map[string]string{}, nil})
+	_ = apiRouter.Get("/users/account", testHandlerFunc, MatchingOptions{Host: "api.test.com"})
 	_ = mainRouter.Prefix("/api", &apiRouter)
 
 	req, _ := http.NewRequest("GET", "/users/1/create", nil)
@@ -465,8 +509,8 @@ func TestRouter_MatchingOptions_MatchesBySchemas(t *testing.T) {
 	mainRouter := Router{}
 
 	_ = mainRouter.Get("/users", testHandlerFunc, NewMatchingOptions())
-	_ = mainRouter.Get("/users/{id}", testHandlerFunc, MatchingOptions{"", "", []string{"Http", "ftp"}, map[string]string{}, map[string]string{}, nil})
-	_ = mainRouter.Get("/users/{id}/create", testHandlerFunc, MatchingOptions{"", "", []string{"https"}, map[string]string{}, map[string]string{}, nil})
+	_ = mainRouter.Get("/users/{id}", testHandlerFunc, MatchingOptions{Schemas: []string{"Http", "ftp"}})
+	_ = mainRouter.Get("/users/{id}/create", testHandlerFunc, MatchingOptions{Schemas: []string{"https"}})
 
 	req, _ := http.NewRequest("GET", "/users/1/create", nil)
 	req.URL.Scheme = "https"
@@ -508,8 +552,8 @@ func TestRouter_MatchingOptions_MatchesByHeaders(t *testing.T) {
 	mainRouter := Router{}
 
 	_ = mainRouter.Get("/users", testHandlerFunc, NewMatchingOptions())
-	_ = mainRouter.Get("/users/{id}", testHandlerFunc, MatchingOptions{"", "", []string{}, map[string]string{"key1": "value1"}, map[string]string{}, nil})
-	_ = mainRouter.Get("/users/{id}/create", testHandlerFunc, MatchingOptions{"", "", []string{}, map[string]string{"key2": "value2"}, map[string]string{}, nil})
+	_ = mainRouter.Get("/users/{id}", testHandlerFunc, MatchingOptions{Headers: map[string]string{"key1": "value1"}})
+	_ = mainRouter.Get("/users/{id}/create", testHandlerFunc, MatchingOptions{Headers: map[string]string{"key2": "value2"}})
 
 	req, _ := http.NewRequest("GET", "/users/1/create", nil)
 	req.Header.Set("key2", "value2")
@@ -544,8 +588,8 @@ func TestRouter_MatchingOptions_MatchesByQueryParameters(t *testing.T) {
 	mainRouter := Router{}
 
 	_ = mainRouter.Get("/users", testHandlerFunc, NewMatchingOptions())
-	_ = mainRouter.Get("/users/{id}", testHandlerFunc, MatchingOptions{"", "", []string{}, map[string]string{}, map[string]string{"key1": "value1"}, nil})
-	_ = mainRouter.Get("/users/{id}/create", testHandlerFunc, MatchingOptions{"", "", []string{}, map[string]string{}, map[string]string{"key2": "value2"}, nil})
+	_ = mainRouter.Get("/users/{id}", testHandlerFunc, MatchingOptions{QueryParams: map[string]string{"key1": "value1"}})
+	_ = mainRouter.Get("/users/{id}/create", testHandlerFunc, MatchingOptions{QueryParams: map[string]string{"key2": "value2"}})
 
 	req, _ := http.NewRequest("GET", "/users/1/create?key2=value2", nil)
 	res := httptest.NewRecorder()
@@ -573,6 +617,275 @@ func TestRouter_MatchingOptions_MatchesByQueryParameters(t *testing.T) {
 	assertEqual(t, 200, res.Code)
 }
 
+func TestRouter_MatchingOptions_MatchesByCookiesRemoteCIDRsAndPorts(t *testing.T) {
+	mainRouter := NewRouter(RouterConfig{TrustedProxies: []string{"10.0.0.1"}})
+
+	_ = mainRouter.Get("/admin", testHandlerFunc, MatchingOptions{
+		Cookies:     map[string]string{"deployment": "blue"},
+		RemoteCIDRs: []string{"192.168.0.0/16"},
+		Ports:       []int{9000},
+	})
+
+	newRequest := func(host, remote, forwardedFor, deployment string) *http.Request {
+		req, _ := http.NewRequest("GET", "/admin", nil)
+		req.Host = host
+		req.RemoteAddr = remote
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		req.AddCookie(&http.Cookie{Name: "deployment", Value: deployment})
+		return req
+	}
+
+	cases := []struct {
+		req  *http.Request
+		code int
+	}{
+		{newRequest("admin.com:9000", "192.168.1.1:5000", "", "blue"), http.StatusOK},
+		{newRequest("admin.com:9000", "10.0.0.1:5000", "192.168.1.1", "blue"), http.StatusOK},
+		{newRequest("admin.com:9000", "10.0.0.1:5000", "8.8.8.8", "blue"), http.StatusNotFound},
+		{newRequest("admin.com:9000", "8.8.8.8:5000", "192.168.1.1", "blue"), http.StatusNotFound},
+		{newRequest("admin.com:9000", "192.168.1.1:5000", "", "green"), http.StatusNotFound},
+		{newRequest("admin.com", "192.168.1.1:5000", "", "blue"), http.StatusNotFound},
+	}
+
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		mainRouter.ServeHTTP(res, c.req)
+		assertEqual(t, c.code, res.Code)
+	}
+}
+
+func TestRouter_MatchingOptions_ReturnsErrorWhenMalformedCIDRs(t *testing.T) {
+	mainRouter := NewRouter()
+	err := mainRouter.Get("/admin", testHandlerFunc, MatchingOptions{RemoteCIDRs: []string{"192.168.0.0/99"}})
+	assertNotNil(t, err)
+
+	mainRouter = NewRouter(RouterConfig{TrustedProxies: []string{"proxy"}})
+	err = mainRouter.Get("/admin", testHandlerFunc, MatchingOptions{RemoteCIDRs: []string{"192.168.0.0/16"}})
+	assertNotNil(t, err)
+
+	err = mainRouter.Get("/admin", testHandlerFunc, MatchingOptions{Cookies: map[string]string{"c": "{"}})
+	assertNotNil(t, err)
+}
+
+func TestRouter_MatchingOptions_MatchesBySchemasBehindTLSAndProxies(t *testing.T) {
+	mainRouter := NewRouter(RouterConfig{TrustedProxies: []string{"10.0.0.0/8"}})
+	forwardedRouter := NewRouter(RouterConfig{TrustedProxies: []string{"10.0.0.0/8"}, ForwardingHeader: ForwardedHeader})
+
+	_ = mainRouter.Get("/secure", testHandlerFunc, MatchingOptions{Schemas: []string{"https"}})
+	_ = forwardedRouter.Get("/secure", testHandlerFunc, MatchingOptions{Schemas: []string{"https"}})
+
+	cases := []struct {
+		router                   *Router
+		remote, forwarded, proto string
+		tls                      bool
+		code                     int
+	}{
+		{&mainRouter, "203.0.113.1:1234", "", "", false, http.StatusNotFound},
+		{&mainRouter, "203.0.113.1:1234", "", "", true, http.StatusOK},
+		{&mainRouter, "203.0.113.1:1234", "", "https", false, http.StatusNotFound},
+		{&mainRouter, "10.0.0.1:1234", "", "https", false, http.StatusOK},
+		{&mainRouter, "10.0.0.1:1234", "", "http", true, http.StatusNotFound},
+		{&mainRouter, "10.0.0.1:1234", "for=198.51.100.1;proto=https", "http", false, http.StatusNotFound},
+		{&forwardedRouter, "10.0.0.1:1234", "for=198.51.100.1;proto=https", "http", false, http.StatusOK},
+		{&forwardedRouter, "10.0.0.1:1234", "for=198.51.100.1;proto=http, for=10.0.0.2;proto=https", "", false, http.StatusNotFound},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/secure", nil)
+		req.URL.Scheme = ""
+		req.RemoteAddr = c.remote
+		if c.tls {
+			req.TLS = &tls.ConnectionState{}
+		}
+		if c.forwarded != "" {
+			req.Header.Set("Forwarded", c.forwarded)
+		}
+		if c.proto != "" {
+			req.Header.Set("X-Forwarded-Proto", c.proto)
+		}
+		res := httptest.NewRecorder()
+		c.router.ServeHTTP(res, req)
+
+		assertEqual(t, c.code, res.Code)
+	}
+}
+
+func TestRouter_NewRouter_WithForceHTTPSEnabled(t *testing.T) {
+	mainRouter := NewRouter(RouterConfig{ForceHTTPS: true})
+
+	_ = mainRouter.Get("/secure", testHandlerFunc, MatchingOptions{Schemas: []string{"https"}})
+	_ = mainRouter.Post("/secure", testHandlerFunc, MatchingOptions{Schemas: []string{"https"}})
+
+	req, _ := http.NewRequest("GET", "/secure?page=1", nil)
+	req.Host = "example.com:8080"
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusMovedPermanently, res.Code)
+	assertStringEqual(t, "https://example.com/secure?page=1", res.Header().Get("Location"))
+
+	req, _ = http.NewRequest("POST", "/secure", nil)
+	req.Host = "example.com"
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusPermanentRedirect, res.Code)
+
+	req, _ = http.NewRequest("GET", "/missing", nil)
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusNotFound, res.Code)
+
+	req, _ = http.NewRequest("GET", "/secure", nil)
+	req.TLS = &tls.ConnectionState{}
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusOK, res.Code)
+}
+
+func TestRouter_NewRouter_WithForceHTTPSEnabledIgnoresSchemeOfRequestLine(t *testing.T) {
+	mainRouter := NewRouter(RouterConfig{ForceHTTPS: true})
+
+	_ = mainRouter.Get("/secure", testHandlerFunc, MatchingOptions{Schemas: []string{"https"}})
+
+	req := httptest.NewRequest("GET", "https://example.com/secure", nil)
+	req.TLS = nil
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusMovedPermanently, res.Code)
+}
+
+func TestRouter_Prefix_RoutesTrustProxiesOfParentRouter(t *testing.T) {
+	mainRouter := NewRouter(RouterConfig{TrustedProxies: []string{"10.0.0.0/8"}, ForceHTTPS: true})
+	prefixedRouter := Router{}
+
+	_ = prefixedRouter.Get("/secure", testHandlerFunc, MatchingOptions{Schemas: []string{"https"}})
+	_ = prefixedRouter.Get("/internal", testHandlerFunc, MatchingOptions{RemoteCIDRs: []string{"192.168.0.0/16"}})
+	_ = mainRouter.Prefix("/api", &prefixedRouter)
+
+	req := httptest.NewRequest("GET", "/api/secure", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusOK, res.Code)
+
+	req = httptest.NewRequest("GET", "/api/internal", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "192.168.1.1")
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusOK, res.Code)
+}
+
+func TestRouter_MatchingOptions_NegotiatesContentAmongRoutesWithSamePath(t *testing.T) {
+	mainRouter := NewRouter()
+
+	_ = mainRouter.Get("/users/{id}", testDummyHandlerFunc, MatchingOptions{Name: "users.v1", Produces: []string{"application/vnd.acme.v1+json"}})
+	_ = mainRouter.Get("/users/{id}", testHandlerFunc, MatchingOptions{Name: "users.v2", Produces: []string{"application/vnd.acme.v2+json"}})
+	_ = mainRouter.Post("/users", testHandlerFunc, MatchingOptions{Consumes: []string{"application/json"}})
+
+	cases := []struct {
+		method, path, accept, contentType string
+		code                              int
+		body                              string
+	}{
+		{"GET", "/users/1", "application/vnd.acme.v1+json", "", http.StatusOK, "dummy"},
+		{"GET", "/users/1", "application/vnd.acme.v2+json", "", http.StatusOK, "/users/1"},
+		{"GET", "/users/1", "application/vnd.acme.v1+json;q=0.5, application/vnd.acme.v2+json", "", http.StatusOK, "/users/1"},
+		{"GET", "/users/1", "application/vnd.acme.v1+json, application/vnd.acme.v2+json;q=0.5", "", http.StatusOK, "dummy"},
+		{"GET", "/users/1", "", "", http.StatusOK, "dummy"},
+		{"GET", "/users/1", "text/html", "", http.StatusNotAcceptable, ""},
+		{"GET", "/accounts/1", "text/html", "", http.StatusNotFound, ""},
+		{"POST", "/users", "", "application/json", http.StatusOK, "/users"},
+		{"POST", "/users", "", "text/html", http.StatusUnsupportedMediaType, ""},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest(c.method, c.path, strings.NewReader("body"))
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+		if c.contentType != "" {
+			req.Header.Set("Content-Type", c.contentType)
+		}
+		res := httptest.NewRecorder()
+		mainRouter.ServeHTTP(res, req)
+
+		assertEqual(t, c.code, res.Code)
+		if c.body != "" {
+			assertStringEqual(t, c.body, res.Body.String())
+		}
+	}
+
+	assertRouteIsGenerated(t, mainRouter, "users.v1", "/users/1", map[string]string{"id": "1"})
+	assertRouteIsGenerated(t, mainRouter, "users.v2", "/users/1", map[string]string{"id": "1"})
+}
+
+func TestRouter_MatchingOptions_KeepsRoutesWithSamePathAndDifferentMatchers(t *testing.T) {
+	mainRouter := Router{}
+
+	_ = mainRouter.Get("/users", testHandlerFunc)
+	_ = mainRouter.Get("/users", testDummyHandlerFunc, MatchingOptions{Host: "admin.com"})
+	_ = mainRouter.Get("/u", testHandlerFunc)
+
+	req, _ := http.NewRequest("GET", "/users", nil)
+	req.Host = "admin.com"
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertStringEqual(t, "dummy", res.Body.String())
+
+	req.Host = "public.com"
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertStringEqual(t, "/users", res.Body.String())
+}
+
+func TestRouter_MatchingOptions_OverridesRoutesWithSamePathAndMatchers(t *testing.T) {
+	mainRouter := NewRouter(RouterConfig{EnableAutoMethodOptions: true})
+
+	_ = mainRouter.Get("/users", testHandlerFunc, MatchingOptions{Host: "admin.com", Schemas: []string{"http", "https"}})
+	_ = mainRouter.Get("/users", testHandlerFunc, MatchingOptions{Host: "public.com"})
+	_ = mainRouter.Get("/users", testDummyHandlerFunc, MatchingOptions{Host: "ADMIN.com", Schemas: []string{"https", "HTTP"}})
+	_ = mainRouter.Post("/users", testHandlerFunc, MatchingOptions{Host: "admin.com"})
+	_ = mainRouter.Put("/users", testHandlerFunc, MatchingOptions{Host: "admin.com"})
+
+	req, _ := http.NewRequest("GET", "/users", nil)
+	req.Host = "admin.com"
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertStringEqual(t, "dummy", res.Body.String())
+
+	assertEqual(t, 2, len(mainRouter.trees[http.MethodGet].routes()))
+	assertEqual(t, 3, len(mainRouter.trees[http.MethodOptions].routes()))
+}
+
+func TestGetURLParameters_WorksForRoutesWithSamePath(t *testing.T) {
+	mainRouter := Router{}
+	postsRouter := Router{}
+
+	bag := newURLParameterBag(2)
+	bag.add("lang", "en")
+	bag.add("id", "100")
+	_ = postsRouter.Get("/{id}", testDummyHandlerFunc)
+	_ = postsRouter.Get("/{id}", assertRequestHasParameterHandler(t, bag), MatchingOptions{Headers: map[string]string{"X-Version": "2"}})
+	_ = mainRouter.Prefix("/posts/{lang}", &postsRouter)
+
+	req, _ := http.NewRequest("GET", "/posts/en/100", nil)
+	req.Header.Set("X-Version", "2")
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertStringEqual(t, "/posts/en/100", res.Body.String())
+}
+
+func TestRouter_MatchingOptions_ReturnsErrorWhenMalformedMediaTypes(t *testing.T) {
+	mainRouter := Router{}
+
+	err := mainRouter.Get("/users", testHandlerFunc, MatchingOptions{Produces: []string{"json"}})
+	assertNotNil(t, err)
+
+	err = mainRouter.Get("/users", testHandlerFunc, MatchingOptions{Consumes: []string{"json"}})
+	assertNotNil(t, err)
+}
+
 func TestRouter_MatchingOptions_MatchesByCustomMatcher(t *testing.T) {
 	mainRouter := Router{}
 
@@ -580,8 +893,8 @@ func TestRouter_MatchingOptions_MatchesByCustomMatcher(t *testing.T) {
 		return strings.Contains(r.URL.RawQuery, "2")
 	}
 	_ = mainRouter.Get("/users", testHandlerFunc, NewMatchingOptions())
-	_ = mainRouter.Get("/users/{id}", testHandlerFunc, MatchingOptions{"", "", []string{}, map[string]string{}, map[string]string{}, queryHasNumber2})
-	_ = mainRouter.Get("/users/{id}/create", testHandlerFunc, MatchingOptions{"", "", []string{}, map[string]string{}, map[string]string{}, queryHasNumber2})
+	_ = mainRouter.Get("/users/{id}", testHandlerFunc, MatchingOptions{Custom: queryHasNumber2})
+	_ = mainRouter.Get("/users/{id}/create", testHandlerFunc, MatchingOptions{Custom: queryHasNumber2})
 
 	req, _ := http.NewRequest("GET", "/users/1/create?key2=value2", nil)
 	res := httptest.NewRecorder()
@@ -609,10 +922,37 @@ func TestRouter_MatchingOptions_MatchesByCustomMatcher(t *testing.T) {
 	assertEqual(t, 200, res.Code)
 }
 
+func TestRouter_MatchingOptions_MatchesByHostWithPortsAndWildcards(t *testing.T) {
+	mainRouter := Router{}
+
+	bag := newURLParameterBag(2)
+	bag.add("id", "1")
+	bag.add("tenant", "acme")
+	_ = mainRouter.Get("/users/{id}", assertRequestHasParameterHandler(t, bag), MatchingOptions{Host: "{tenant}.example.com:8080"})
+	_ = mainRouter.Get("/accounts", testHandlerFunc, MatchingOptions{Host: "*.example.com"})
+
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	req.Host = "ACME.example.com:8080"
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, 200, res.Code)
+
+	req.Host = "acme.example.com:9090"
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, 404, res.Code)
+
+	req, _ = http.NewRequest("GET", "/accounts", nil)
+	req.Host = "api.example.com:8080"
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, 200, res.Code)
+}
+
 func TestRouter_MatchingOptions_MatchesByHostReturnsErrorWhenMalformedHost(t *testing.T) {
 	mainRouter := Router{}
 
-	err := mainRouter.Get("/users", testHandlerFunc, MatchingOptions{"", "app.{subdomain:[a-z]+}{m}.test2.com", []string{}, map[string]string{}, map[string]string{}, nil})
+	err := mainRouter.Get("/users", testHandlerFunc, MatchingOptions{Host: "app.{subdomain:[a-z]+}{m}.test2.com"})
 	assertNotNil(t, err)
 }
 
@@ -811,9 +1151,9 @@ func TestRouter_NewRouter_WithAutoMethodOptionsEnabled(t *testing.T) {
 	optionsResponse := httptest.NewRecorder()
 	mainRouter.ServeHTTP(optionsResponse, req)
 	assertEqual(t, http.StatusNoContent, optionsResponse.Code)
-	assertStringContains(t, "GET", optionsResponse.Header().Get("Allow"))
-	assertStringContains(t, "DELETE", optionsResponse.Header().Get("Allow"))
-	assertStringContains(t, "OPTIONS", optionsResponse.Header().Get("Allow"))
+	assertStringContains(t, "GET", optionsResponse.Result().Header.Get("Allow"))
+	assertStringContains(t, "DELETE", optionsResponse.Result().Header.Get("Allow"))
+	assertStringContains(t, "OPTIONS", optionsResponse.Result().Header.Get("Allow"))
 }
 
 func TestRouter_Register_CanOverrideRouteHandler(t *testing.T) {
@@ -1048,10 +1388,11 @@ func (l *sliceLoader) Load() []RouteDef {
 }
 
 func TestRouter_Load_RegisterRoutes(t *testing.T) {
-	AddHandler(testHandlerFunc, "users.Handler")
-	AddCustomMatcher(testCustomMatcher, "true.CustomMatcher")
+	registry := NewRegistry()
+	assertNil(t, registry.AddHandler(testHandlerFunc, "users.Handler"))
+	assertNil(t, registry.AddCustomMatcher(testCustomMatcher, "true.CustomMatcher"))
 
-	router := NewRouter()
+	router := NewRouter(RouterConfig{Registry: registry})
 	loader := sliceLoader{
 		RouteDef{
 			Method:  "GET",
@@ -1070,10 +1411,70 @@ func TestRouter_Load_RegisterRoutes(t *testing.T) {
 	assertPathFound(t, router, "GET", "/users")
 }
 
+func TestRouter_Load_WrapsHandlersWithMiddlewares(t *testing.T) {
+	registry := NewRegistry()
+	assertNil(t, registry.AddHandler(testHandlerFunc, "users.Handler"))
+	assertNil(t, registry.AddMiddleware(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Middleware", "first")
+			next(w, r)
+		}
+	}, "first.Middleware"))
+	assertNil(t, registry.AddMiddleware(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Middleware", "second")
+			next(w, r)
+		}
+	}, "second.Middleware"))
+
+	router := NewRouter(RouterConfig{Registry: registry})
+	loader := sliceLoader{
+		RouteDef{
+			Method:  "GET",
+			Path:    "/users",
+			Handler: "users.Handler",
+			Options: RouteDefOptions{
+				Name:        "get.users",
+				Middlewares: []string{"first.Middleware", "second.Middleware"},
+			},
+		},
+	}
+	err := router.Load(&loader)
+	assertNil(t, err)
+
+	r, _ := http.NewRequest("GET", "/users", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	assertStringEqual(t, "/users", w.Body.String())
+	assertStringEqual(t, "first, second", strings.Join(w.Header()["X-Middleware"], ", "))
+}
+
+func TestRouter_Load_FailsWhenMiddlewareDoesNotExist(t *testing.T) {
+	registry := NewRegistry()
+	assertNil(t, registry.AddHandler(testHandlerFunc, "users.Handler"))
+
+	router := NewRouter(RouterConfig{Registry: registry})
+	loader := sliceLoader{
+		RouteDef{
+			Method:  "GET",
+			Path:    "/users",
+			Handler: "users.Handler",
+			Options: RouteDefOptions{
+				Name:        "get.users",
+				Middlewares: []string{"notExists.Middleware"},
+			},
+		},
+	}
+	err := router.Load(&loader)
+	assertNotNil(t, err)
+}
+
 func TestRouter_Load_FailsWhenCustomMatcherDoesNotExist(t *testing.T) {
-	AddHandler(testHandlerFunc, "users.Handler")
+	registry := NewRegistry()
+	assertNil(t, registry.AddHandler(testHandlerFunc, "users.Handler"))
 
-	router := NewRouter()
+	router := NewRouter(RouterConfig{Registry: registry})
 	loader := sliceLoader{
 		RouteDef{
 			Method:  "GET",
@@ -1090,9 +1491,10 @@ func TestRouter_Load_FailsWhenCustomMatcherDoesNotExist(t *testing.T) {
 }
 
 func TestRouter_Load_FailsWhenHandlerDoesNotExist(t *testing.T) {
-	AddHandler(testHandlerFunc, "users.Handler")
+	registry := NewRegistry()
+	assertNil(t, registry.AddHandler(testHandlerFunc, "users.Handler"))
 
-	router := NewRouter()
+	router := NewRouter(RouterConfig{Registry: registry})
 	loader := sliceLoader{
 		RouteDef{
 			Method:  "GET",
@@ -1108,9 +1510,10 @@ func TestRouter_Load_FailsWhenHandlerDoesNotExist(t *testing.T) {
 }
 
 func TestRouter_Load_FailsWhenPathIsInvalid(t *testing.T) {
-	AddHandler(testHandlerFunc, "users.Handler")
+	registry := NewRegistry()
+	assertNil(t, registry.AddHandler(testHandlerFunc, "users.Handler"))
 
-	router := NewRouter()
+	router := NewRouter(RouterConfig{Registry: registry})
 	loader := sliceLoader{
 		RouteDef{
 			Method:  "GET",
@@ -1126,9 +1529,10 @@ func TestRouter_Load_FailsWhenPathIsInvalid(t *testing.T) {
 }
 
 func TestRouter_Load_FailsWhenMethodIsInvalid(t *testing.T) {
-	AddHandler(testHandlerFunc, "users.Handler")
+	registry := NewRegistry()
+	assertNil(t, registry.AddHandler(testHandlerFunc, "users.Handler"))
 
-	router := NewRouter()
+	router := NewRouter(RouterConfig{Registry: registry})
 	loader := sliceLoader{
 		RouteDef{
 			Method:  "ME",
@@ -1160,3 +1564,26 @@ func assertStringContains(t *testing.T, expected, value string) {
 		t.Errorf("%v does not contain %v", value, expected)
 	}
 }
+
+func TestRouteName_ReturnsMatchedRouteName(t *testing.T) {
+	mainRouter := Router{}
+	postsRouter := Router{}
+
+	var name string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		name = RouteName(r)
+	}
+	_ = mainRouter.Get("/users", handler, MatchingOptions{Name: "users"})
+	_ = postsRouter.Get("/{id}", handler, MatchingOptions{Name: "users"})
+	_ = mainRouter.Prefix("/posts", &postsRouter)
+
+	req, _ := http.NewRequest("GET", "/users", nil)
+	mainRouter.ServeHTTP(httptest.NewRecorder(), req)
+	assertStringEqual(t, "users", name)
+
+	req, _ = http.NewRequest("GET", "/posts/1", nil)
+	mainRouter.ServeHTTP(httptest.NewRecorder(), req)
+	assertStringEqual(t, "users_1", name)
+
+	assertStringEqual(t, "", RouteName(req))
+}
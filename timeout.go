@@ -0,0 +1,139 @@
+package routing
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// TimeoutOptions configures the response of the Timeout middleware
+type TimeoutOptions struct {
+	// Code is the status code of the response when the deadline passes, it
+	// defaults to 503 Service Unavailable
+	Code int
+	// Body is the body of the response when the deadline passes
+	Body string
+}
+
+// Timeout returns a Middleware running the next handlers with a deadline
+// derived from the request context, like http.TimeoutHandler does. The
+// response is buffered and only sent if the handlers finish before the
+// deadline, otherwise the timeout response is sent and later writes fail with
+// http.ErrHandlerTimeout. The timeout of routes registered with
+// MatchingOptions.Timeout takes precedence over the one of the middleware
+// only when it wraps the route handlers, as a route middleware or one of
+// RouterConfig.Middlewares. Wrapping a Router, the deadline is set before the
+// route is matched and applies to all of them.
+func Timeout(timeout time.Duration, options ...TimeoutOptions) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		h := timeoutHandler(timeout, next, options...)
+
+		return func(w http.ResponseWriter, r *http.Request) {
+			if leaf, ok := r.Context().Value(ctxKey).(*node); ok && leaf.timeout > 0 {
+				next(w, r)
+				return
+			}
+
+			h(w, r)
+		}
+	}
+}
+
+func timeoutHandler(timeout time.Duration, next http.HandlerFunc, options ...TimeoutOptions) http.HandlerFunc {
+	opts := TimeoutOptions{}
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.Code == 0 {
+		opts.Code = http.StatusServiceUnavailable
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		tw := &timeoutWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		panics := make(chan interface{}, 1)
+
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					if slot := getRequestSlot(r); slot != nil {
+						slot.setPanicStack(debug.Stack())
+					}
+					panics <- p
+				}
+			}()
+
+			next(tw, r)
+			close(done)
+		}()
+
+		select {
+		case p := <-panics:
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			for k, v := range tw.header {
+				w.Header()[k] = v
+			}
+			if tw.code == 0 {
+				tw.code = http.StatusOK
+			}
+			w.WriteHeader(tw.code)
+			_, _ = w.Write(tw.buf.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			tw.timedOut = true
+			w.WriteHeader(opts.Code)
+			_, _ = w.Write([]byte(opts.Body))
+		}
+	}
+}
+
+// timeoutWriter buffers the response of a handler run with a deadline
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
@@ -0,0 +1,177 @@
+package routing
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func sleepingHandler(d time.Duration, written chan<- error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(d)
+
+		w.Header().Set("X-Slept", d.String())
+		w.WriteHeader(http.StatusCreated)
+		_, err := w.Write([]byte("slept"))
+		if written != nil {
+			written <- err
+		}
+	}
+}
+
+func TestTimeout_SendsResponseWhenHandlerFinishesInTime(t *testing.T) {
+	handler := Timeout(time.Second)(sleepingHandler(0, nil))
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	handler(res, req)
+
+	assertEqual(t, http.StatusCreated, res.Code)
+	assertStringEqual(t, "0s", res.Header().Get("X-Slept"))
+	assertStringEqual(t, "slept", res.Body.String())
+}
+
+func TestTimeout_RespondsWhenDeadlinePasses(t *testing.T) {
+	written := make(chan error, 1)
+	handler := Timeout(10*time.Millisecond, TimeoutOptions{Code: http.StatusGatewayTimeout, Body: "too slow"})(sleepingHandler(100*time.Millisecond, written))
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	handler(res, req)
+
+	assertEqual(t, http.StatusGatewayTimeout, res.Code)
+	assertStringEqual(t, "too slow", res.Body.String())
+	assertStringEqual(t, "", res.Header().Get("X-Slept"))
+	assertTrue(t, <-written == http.ErrHandlerTimeout)
+}
+
+func TestTimeout_DerivesDeadlineFromRequestContext(t *testing.T) {
+	var deadline time.Time
+	handler := Timeout(time.Minute)(func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ = r.Context().Deadline()
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler(httptest.NewRecorder(), req)
+
+	assertTrue(t, time.Until(deadline) > 59*time.Second)
+}
+
+func TestTimeout_PropagatesPanics(t *testing.T) {
+	handler := Timeout(time.Second)(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	defer func() {
+		assertTrue(t, recover() == "boom")
+	}()
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler(httptest.NewRecorder(), req)
+}
+
+func TestRouter_MatchingOptions_TimeoutOverridesMiddleware(t *testing.T) {
+	mainRouter := Router{}
+	pipe := NewMiddlewarePipe().Next(Timeout(10 * time.Millisecond))
+
+	_ = mainRouter.Get("/users", pipe.Then(sleepingHandler(50*time.Millisecond, nil)))
+	_ = mainRouter.Get("/reports", pipe.Then(sleepingHandler(50*time.Millisecond, nil)), MatchingOptions{Timeout: time.Second})
+
+	req, _ := http.NewRequest("GET", "/users", nil)
+	res := httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusServiceUnavailable, res.Code)
+
+	req, _ = http.NewRequest("GET", "/reports", nil)
+	res = httptest.NewRecorder()
+	mainRouter.ServeHTTP(res, req)
+	assertEqual(t, http.StatusCreated, res.Code)
+}
+
+func TestRouter_MatchingOptions_TimeoutOverridesRouterMiddlewares(t *testing.T) {
+	var deadlines []bool
+	recordDeadline := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			_, ok := r.Context().Deadline()
+			deadlines = append(deadlines, ok)
+			next(w, r)
+		}
+	}
+
+	config := RouterConfig{Middlewares: []Middleware{recordDeadline, Timeout(10 * time.Millisecond)}}
+	mainRouter := NewRouter(config)
+	_ = mainRouter.Get("/users", sleepingHandler(50*time.Millisecond, nil))
+	_ = mainRouter.Get("/reports", sleepingHandler(50*time.Millisecond, nil), MatchingOptions{Timeout: time.Second})
+
+	subRouter := NewRouter()
+	_ = subRouter.Get("/reports", sleepingHandler(50*time.Millisecond, nil), MatchingOptions{Timeout: time.Second})
+	_ = mainRouter.Prefix("/admin", &subRouter)
+
+	cases := []struct {
+		path string
+		code int
+	}{
+		{"/users", http.StatusServiceUnavailable},
+		{"/reports", http.StatusCreated},
+		{"/admin/reports", http.StatusCreated},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", c.path, nil)
+		res := httptest.NewRecorder()
+		mainRouter.ServeHTTP(res, req)
+		assertEqual(t, c.code, res.Code)
+	}
+
+	assertEqual(t, 3, len(deadlines))
+	for _, hasDeadline := range deadlines {
+		assertFalse(t, hasDeadline)
+	}
+}
+
+func TestTimeout_AppliesToAllRoutesWhenWrappingRouter(t *testing.T) {
+	mainRouter := Router{}
+	_ = mainRouter.Get("/reports", sleepingHandler(50*time.Millisecond, nil), MatchingOptions{Timeout: time.Second})
+	handler := Timeout(10 * time.Millisecond)(mainRouter.ServeHTTP)
+
+	req, _ := http.NewRequest("GET", "/reports", nil)
+	res := httptest.NewRecorder()
+	handler(res, req)
+	assertEqual(t, http.StatusServiceUnavailable, res.Code)
+}
+
+func TestTimeout_LetsWrappingMiddlewaresReadMatchedRouteAfterDeadline(t *testing.T) {
+	var out bytes.Buffer
+
+	mainRouter := Router{}
+	_ = mainRouter.Get("/reports", sleepingHandler(50*time.Millisecond, nil), MatchingOptions{Name: "reports"})
+	handler := NewMiddlewarePipe().Next(Logger(LoggerOptions{Output: &out}), Timeout(10*time.Millisecond)).Then(mainRouter.ServeHTTP)
+
+	req, _ := http.NewRequest("GET", "/reports", nil)
+	res := httptest.NewRecorder()
+	handler(res, req)
+	assertEqual(t, http.StatusServiceUnavailable, res.Code)
+	assertStringContains(t, "503", out.String())
+}
+
+func panickingHandler(w http.ResponseWriter, r *http.Request) {
+	panic("boom")
+}
+
+func TestTimeout_ReportsStackOfPanickingHandler(t *testing.T) {
+	var stack []byte
+	handler := NewMiddlewarePipe().Next(
+		Recover(RecoverOptions{Reporter: func(r *http.Request, recovered interface{}, s []byte) {
+			stack = s
+		}}),
+		Timeout(time.Second),
+	).Then(panickingHandler)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	handler(res, req)
+	assertEqual(t, http.StatusInternalServerError, res.Code)
+	assertStringContains(t, "panickingHandler", string(stack))
+}
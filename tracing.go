@@ -0,0 +1,207 @@
+package routing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+	traceVersion      = "00"
+	traceFlagSampled  = 0x01
+)
+
+// TraceContext is the context of a span propagated between services with the
+// W3C Trace Context traceparent and tracestate headers
+type TraceContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+	State   string
+}
+
+// IsValid reports whether both the trace and span IDs are set
+func (tc TraceContext) IsValid() bool {
+	return tc.TraceID != [16]byte{} && tc.SpanID != [8]byte{}
+}
+
+// IsSampled reports whether the sampled flag is set
+func (tc TraceContext) IsSampled() bool {
+	return tc.Flags&traceFlagSampled != 0
+}
+
+// String returns the trace context formatted as a traceparent header value
+func (tc TraceContext) String() string {
+	return traceVersion + "-" + hex.EncodeToString(tc.TraceID[:]) + "-" +
+		hex.EncodeToString(tc.SpanID[:]) + "-" + hex.EncodeToString([]byte{tc.Flags})
+}
+
+// Inject sets the traceparent and tracestate headers, for instance of an
+// outgoing request, to propagate the trace context
+func (tc TraceContext) Inject(header http.Header) {
+	if !tc.IsValid() {
+		return
+	}
+
+	header.Set(traceparentHeader, tc.String())
+	if tc.State != "" {
+		header.Set(tracestateHeader, tc.State)
+	} else {
+		header.Del(tracestateHeader)
+	}
+}
+
+// ParseTraceparent parses a traceparent header value. Values of future
+// versions are parsed as version 00 as mandated by the specification.
+func ParseTraceparent(value string) (TraceContext, error) {
+	var tc TraceContext
+
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return tc, fmt.Errorf("invalid traceparent %s", value)
+	}
+
+	version, err := strconv.ParseUint(parts[0], 16, 8)
+	if err != nil || version == 0xff || (version == 0 && len(parts) != 4) || strings.ToLower(value) != value {
+		return tc, fmt.Errorf("invalid traceparent %s", value)
+	}
+
+	var flags [1]byte
+	if _, err := hex.Decode(tc.TraceID[:], []byte(parts[1])); err != nil {
+		return tc, fmt.Errorf("invalid traceparent %s: %w", value, err)
+	}
+	if _, err := hex.Decode(tc.SpanID[:], []byte(parts[2])); err != nil {
+		return tc, fmt.Errorf("invalid traceparent %s: %w", value, err)
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return tc, fmt.Errorf("invalid traceparent %s: %w", value, err)
+	}
+	tc.Flags = flags[0]
+
+	if !tc.IsValid() {
+		return tc, fmt.Errorf("invalid traceparent %s", value)
+	}
+
+	return tc, nil
+}
+
+// Span is an operation traced by a Tracer
+type Span interface {
+	// TraceContext returns the context of the span to propagate
+	TraceContext() TraceContext
+	// SetName renames the span, for instance once the route is known
+	SetName(name string)
+	// SetAttribute annotates the span
+	SetAttribute(key string, value interface{})
+	// End finishes the span
+	End()
+}
+
+// Tracer starts spans. It is the extension point to plug tracing libraries,
+// like OpenTelemetry, through small adapters.
+type Tracer interface {
+	// Start starts a span child of the remote parent, which is not valid when
+	// the request did not come with one, and returns the context holding it
+	Start(ctx context.Context, name string, parent TraceContext) (context.Context, Span)
+}
+
+// TracingOptions are the options of the Tracing middleware
+type TracingOptions struct {
+	// Tracer starts the spans of the requests. When nil the trace context is
+	// only propagated, with a new span ID per request and sampled new traces.
+	Tracer Tracer
+}
+
+type traceKey int
+
+var traceCtxKey traceKey
+
+// Tracing returns a Middleware starting a span per request, child of the one
+// of the traceparent header if any. Spans are named after the method and the
+// template of the matched route, and annotated with them and the status code.
+// The trace context of the span is available with GetTraceContext, to inject
+// it into outgoing requests.
+func Tracing(options ...TracingOptions) Middleware {
+	var opts TracingOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	tracer := opts.Tracer
+	if tracer == nil {
+		tracer = propagatingTracer{}
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			parent, err := ParseTraceparent(r.Header.Get(traceparentHeader))
+			if err == nil {
+				parent.State = r.Header.Get(tracestateHeader)
+			} else {
+				parent = TraceContext{}
+			}
+
+			ctx, span := tracer.Start(r.Context(), r.Method, parent)
+			defer span.End()
+
+			r = r.WithContext(context.WithValue(ctx, traceCtxKey, span.TraceContext()))
+			w, rw := wrapResponseWriter(w)
+			r, _ = withRequestSlot(r)
+
+			next(w, r)
+
+			status := rw.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			span.SetAttribute("http.request.method", r.Method)
+			span.SetAttribute("http.response.status_code", status)
+			if template := RouteTemplate(r); template != "" {
+				span.SetName(r.Method + " " + template)
+				span.SetAttribute("http.route", template)
+			}
+		}
+	}
+}
+
+// GetTraceContext returns the trace context of the span of the request started
+// by the Tracing middleware, which is not valid if there is none
+func GetTraceContext(r *http.Request) TraceContext {
+	tc, _ := r.Context().Value(traceCtxKey).(TraceContext)
+	return tc
+}
+
+// propagatingTracer starts spans that are not recorded, only propagated
+type propagatingTracer struct{}
+
+func (propagatingTracer) Start(ctx context.Context, name string, parent TraceContext) (context.Context, Span) {
+	tc := parent
+	if !tc.IsValid() {
+		tc = TraceContext{Flags: traceFlagSampled}
+		_, _ = rand.Read(tc.TraceID[:])
+	}
+	_, _ = rand.Read(tc.SpanID[:])
+
+	return ctx, propagatingSpan{tc}
+}
+
+type propagatingSpan struct {
+	tc TraceContext
+}
+
+func (s propagatingSpan) TraceContext() TraceContext {
+	return s.tc
+}
+
+func (propagatingSpan) SetName(string) {}
+
+func (propagatingSpan) SetAttribute(string, interface{}) {}
+
+func (propagatingSpan) End() {}
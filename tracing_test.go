@@ -0,0 +1,119 @@
+package routing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testSpan struct {
+	tc         TraceContext
+	name       string
+	parent     TraceContext
+	attributes map[string]interface{}
+	ended      bool
+}
+
+func (s *testSpan) TraceContext() TraceContext {
+	return s.tc
+}
+
+func (s *testSpan) SetName(name string) {
+	s.name = name
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) {
+	s.attributes[key] = value
+}
+
+func (s *testSpan) End() {
+	s.ended = true
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, parent TraceContext) (context.Context, Span) {
+	span := &testSpan{name: name, parent: parent, attributes: map[string]interface{}{}}
+	span.tc = TraceContext{TraceID: parent.TraceID, SpanID: [8]byte{1}, Flags: parent.Flags, State: parent.State}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assertNil(t, err)
+	assertTrue(t, tc.IsValid())
+	assertTrue(t, tc.IsSampled())
+	assertStringEqual(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", tc.String())
+
+	_, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+	assertNil(t, err)
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+	}
+	for _, value := range invalid {
+		_, err := ParseTraceparent(value)
+		assertNotNil(t, err)
+	}
+}
+
+func TestTracing_StartsSpansChildOfTheTraceparent(t *testing.T) {
+	tracer := &testTracer{}
+
+	mainRouter := Router{}
+	_ = mainRouter.Get("/users/{id}", testHandlerFunc)
+	handler := NewMiddlewarePipe().Next(Tracing(TracingOptions{Tracer: tracer})).Then(mainRouter.ServeHTTP)
+
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("tracestate", "vendor=value")
+	handler(httptest.NewRecorder(), req)
+
+	assertEqual(t, 1, len(tracer.spans))
+	span := tracer.spans[0]
+	assertStringEqual(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", span.parent.String())
+	assertStringEqual(t, "vendor=value", span.parent.State)
+	assertStringEqual(t, "GET /users/{id}", span.name)
+	assertTrue(t, span.attributes["http.route"] == "/users/{id}")
+	assertTrue(t, span.attributes["http.response.status_code"] == http.StatusOK)
+	assertTrue(t, span.ended)
+}
+
+func TestTracing_PropagatesTheTraceContextWithoutTracer(t *testing.T) {
+	var tc TraceContext
+	handler := NewMiddlewarePipe().Next(Tracing()).Then(func(w http.ResponseWriter, r *http.Request) {
+		tc = GetTraceContext(r)
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	handler(httptest.NewRecorder(), req)
+
+	parent, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	assertTrue(t, tc.IsValid())
+	assertTrue(t, tc.TraceID == parent.TraceID)
+	assertTrue(t, tc.SpanID != parent.SpanID)
+	assertFalse(t, tc.IsSampled())
+
+	header := http.Header{}
+	tc.Inject(header)
+	assertStringEqual(t, tc.String(), header.Get("traceparent"))
+
+	req, _ = http.NewRequest("GET", "/", nil)
+	handler(httptest.NewRecorder(), req)
+
+	assertTrue(t, tc.IsValid())
+	assertTrue(t, tc.TraceID != parent.TraceID)
+	assertTrue(t, tc.IsSampled())
+}
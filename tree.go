@@ -14,14 +14,70 @@ type tree struct {
 }
 
 func (t *tree) insert(chunks []chunk, handler http.HandlerFunc) *node {
+	return t.insertRoute(chunks, &node{routeAttributes: routeAttributes{handler: handler}})
+}
+
+// insertRoute inserts a route whose leaf takes the handler and the matching
+// options of the given route node
+func (t *tree) insertRoute(chunks []chunk, route *node) *node {
 	root2, leaf2 := createTreeFromChunks(chunks)
-	leaf2.handler = handler
+	leaf2.routeAttributes = route.routeAttributes
 
 	t.root = combine(t.root, root2)
 
 	return leaf2
 }
 
+// mergeRoutes merges the route of a node into another one with the same
+// path, replacing it or the alternative with the same matching options, or
+// adding it as an alternative otherwise
+func mergeRoutes(n1, n2 *node) {
+	if n2.handler == nil {
+		return
+	}
+
+	if n2.replaces(&n1.routeAttributes) {
+		n1.routeAttributes = n2.routeAttributes
+		return
+	}
+
+	for _, alternative := range n1.alternatives {
+		if n2.replaces(&alternative.routeAttributes) {
+			alternative.routeAttributes = n2.routeAttributes
+			return
+		}
+	}
+
+	n2.primary = n1
+	n1.alternatives = append(n1.alternatives, n2)
+}
+
+// routes returns the nodes of the tree holding a route, alternatives included
+func (t *tree) routes() []*node {
+	var routes []*node
+
+	var walk func(n *node)
+	walk = func(n *node) {
+		for ; n != nil; n = n.sibling {
+			if n.handler != nil {
+				routes = append(routes, n)
+			}
+			routes = append(routes, n.alternatives...)
+
+			if n.t == nodeTypeStatic {
+				walk(n.child)
+				continue
+			}
+			for _, next := range n.stops {
+				walk(next)
+			}
+		}
+	}
+	walk(t.root)
+
+	return routes
+}
+
 func combine(tree1 *node, tree2 *node) *node {
 
 	if tree1 == nil {
@@ -53,9 +109,7 @@ func combine(tree1 *node, tree2 *node) *node {
 			}
 
 			tree1.stops = tree2.stops
-			if tree2.handler != nil {
-				tree1.handler = tree2.handler
-			}
+			mergeRoutes(tree1, tree2)
 
 			return tree1
 		}
@@ -119,9 +173,7 @@ func combine(tree1 *node, tree2 *node) *node {
 		return split
 	}
 
-	if tree2.handler != nil {
-		tree1.handler = tree2.handler
-	}
+	mergeRoutes(tree1, tree2)
 
 	if tree1.child == nil && tree2.child == nil {
 		return tree1
@@ -158,11 +210,11 @@ func createTreeFromChunks(chunks []chunk) (root, leaf *node) {
 func createNodeFromChunk(c chunk) *node {
 	var n *node
 	if c.t == tChunkStatic {
-		n = &node{prefix: c.v, handler: nil, t: nodeTypeStatic}
+		n = &node{prefix: c.v, t: nodeTypeStatic}
 	} else {
 		stops := make(map[byte]*node)
 
-		n = &node{prefix: c.v, t: nodeTypeDynamic, handler: nil, stops: stops, regexp: c.exp}
+		n = &node{prefix: c.v, t: nodeTypeDynamic, stops: stops, regexp: c.exp}
 	}
 	return n
 }
@@ -199,13 +251,15 @@ func find(n *node, p string, request *http.Request) *node {
 			}
 		}
 
-		if n.match(request) && !traversed {
+		if !traversed {
 			validExpression := true
 			if n.regexp != nil {
 				validExpression = n.regexp.MatchString(p)
 			}
 			if validExpression {
-				return n
+				if h := n.resolve(request); h != nil {
+					return h
+				}
 			}
 		}
 
@@ -218,11 +272,7 @@ func find(n *node, p string, request *http.Request) *node {
 	}
 
 	if pos == len(p) && len(p) == len(n.prefix) {
-		if n.match(request) {
-			return n
-		}
-
-		return nil
+		return n.resolve(request)
 	}
 
 	h := find(n.child, p[pos:], request)
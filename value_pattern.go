@@ -0,0 +1,109 @@
+package routing
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultValueExpression = ".+?"
+	valueGroupPrefix       = "__routing_"
+)
+
+// valuePattern matches header or query parameter values against a template
+// with the same {name:regexp} syntax of paths, for instance
+// application/vnd.acme.v{version:[0-9]+}+json. An empty template only checks
+// the value presence and a template without variables requires equality.
+type valuePattern struct {
+	template string
+	regexp   *regexp.Regexp
+	names    []string
+	groups   []int
+}
+
+func newValuePattern(template string) (*valuePattern, error) {
+	p := &valuePattern{template: template}
+	if !strings.ContainsAny(template, "{}") {
+		return p, nil
+	}
+
+	parser := newValueParser(template)
+	if _, err := parser.parse(); err != nil {
+		return nil, fmt.Errorf("value pattern %s is malformed: %v", template, err)
+	}
+
+	var exp bytes.Buffer
+	exp.WriteString("^")
+	for _, chunk := range parser.chunks {
+		if chunk.t == tChunkStatic {
+			exp.WriteString(regexp.QuoteMeta(chunk.v))
+			continue
+		}
+
+		varExp := defaultValueExpression
+		if chunk.exp != nil {
+			varExp = chunk.exp.String()
+		}
+
+		fmt.Fprintf(&exp, "(?P<%s%d>%s)", valueGroupPrefix, len(p.names), varExp)
+		p.names = append(p.names, chunk.v)
+	}
+	exp.WriteString("$")
+
+	rex, err := regexp.Compile(exp.String())
+	if err != nil {
+		return nil, err
+	}
+	p.regexp = rex
+
+	p.groups = make([]int, len(p.names))
+	for i, group := range rex.SubexpNames() {
+		if !strings.HasPrefix(group, valueGroupPrefix) {
+			continue
+		}
+		index, err := strconv.Atoi(group[len(valueGroupPrefix):])
+		if err == nil && index < len(p.groups) {
+			p.groups[index] = i
+		}
+	}
+
+	return p, nil
+}
+
+func (p *valuePattern) hasParameters() bool {
+	return len(p.names) > 0
+}
+
+// match checks if any of the values matches the pattern, returning the
+// parameters captured from the first one matching
+func (p *valuePattern) match(values []string) (bool, URLParameterBag) {
+	if p.template == "" {
+		return len(values) > 0, newURLParameterBag(0)
+	}
+
+	for _, value := range values {
+		if p.regexp == nil {
+			if value == p.template {
+				return true, newURLParameterBag(0)
+			}
+			continue
+		}
+
+		submatches := p.regexp.FindStringSubmatch(value)
+		if submatches == nil {
+			continue
+		}
+
+		bag := newURLParameterBag(uint(len(p.names)))
+		for i, name := range p.names {
+			bag.add(name, submatches[p.groups[i]])
+		}
+
+		return true, bag
+	}
+
+	return false, newURLParameterBag(0)
+}
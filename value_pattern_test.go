@@ -0,0 +1,77 @@
+package routing
+
+import "testing"
+
+func Test_newValuePattern_ReturnsErrorWhenMalformed(t *testing.T) {
+	templates := []string{
+		"v{version",
+		"v}version",
+		"v{}",
+		"v{ver-sion}",
+		"v{version:[0-9+}",
+	}
+
+	for _, template := range templates {
+		p, err := newValuePattern(template)
+		assertNil(t, p)
+		if err == nil {
+			t.Errorf("error expected for template %s", template)
+		}
+	}
+}
+
+func Test_valuePattern_MatchesExactValues(t *testing.T) {
+	p, err := newValuePattern("application/json")
+	assertNil(t, err)
+	assertFalse(t, p.hasParameters())
+
+	matches, _ := p.match([]string{"text/html", "application/json"})
+	assertTrue(t, matches)
+
+	matches, _ = p.match([]string{"application/json+ld"})
+	assertFalse(t, matches)
+
+	matches, _ = p.match(nil)
+	assertFalse(t, matches)
+}
+
+func Test_valuePattern_MatchesPresenceWhenEmpty(t *testing.T) {
+	p, err := newValuePattern("")
+	assertNil(t, err)
+
+	matches, _ := p.match([]string{""})
+	assertTrue(t, matches)
+
+	matches, _ = p.match(nil)
+	assertFalse(t, matches)
+}
+
+func Test_valuePattern_CapturesParameters(t *testing.T) {
+	p, err := newValuePattern("application/vnd.{vendor}.v{version:[0-9]+}+json")
+	assertNil(t, err)
+	assertTrue(t, p.hasParameters())
+
+	matches, params := p.match([]string{"application/vnd.acme.v12+json"})
+	assertTrue(t, matches)
+
+	vendor, _ := params.GetByName("vendor")
+	assertStringEqual(t, "acme", vendor)
+	version, _ := params.GetByName("version")
+	assertStringEqual(t, "12", version)
+
+	matches, _ = p.match([]string{"application/vnd.acme.vX+json"})
+	assertFalse(t, matches)
+}
+
+func Test_valuePattern_CapturesParametersWithNestedGroups(t *testing.T) {
+	p, err := newValuePattern("{year:([0-9]{4})}-{month:(?P<m>[0-9]{2})}")
+	assertNil(t, err)
+
+	matches, params := p.match([]string{"2020-05"})
+	assertTrue(t, matches)
+
+	year, _ := params.GetByIndex(0)
+	assertStringEqual(t, "2020", year)
+	month, _ := params.GetByIndex(1)
+	assertStringEqual(t, "05", month)
+}